@@ -1,45 +1,67 @@
 package nuki
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
 	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
 	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"time"
 )
 
 // Pair will perform a pairing process with the connected device.
 // To pair a device, the device must be in pairing mode and a bluetooth connection must be established before.
 // See EstablishConnection to establish a connection. The pairing must be done only once. After the successful
-// pairing the authId, private- and public-key should be saved.
+// pairing the authId, private- and public-key should be saved. The pairing will fail with
+// communication.P_ERROR_BAD_AUTHENTICATOR if the device can not prove the possession of its private key.
 func (c *Client) Pair(ctx context.Context, privateKey, publicKey nacl.Key, id command.ClientId, idType command.ClientIdType, name string) error {
-	if c.client == nil {
+	if err := c.queue.acquire(ctx, priorityNormal); err != nil {
+		return err
+	}
+	defer c.queue.release()
+
+	c.mu.RLock()
+	gdioCom, timeout := c.gdioCom, c.responseTimeout
+	c.mu.RUnlock()
+
+	if gdioCom == nil {
 		return ConnectionNotEstablishedError
 	}
 
-	err := c.gdioCom.Send(command.NewRequest(command.IdPublicKey))
+	err := gdioCom.Send(command.NewRequest(command.IdPublicKey))
 	if err != nil {
 		return fmt.Errorf("unable to send request for public key: %w", err)
 	}
 
-	pubKeyResp, err := c.gdioCom.WaitForSpecificResponse(ctx, command.IdPublicKey, c.responseTimeout)
+	pubKeyResp, err := gdioCom.WaitForSpecificResponse(ctx, command.IdPublicKey, timeout)
 	if err != nil {
 		return fmt.Errorf("error while waiting for public key response: %w", err)
 	}
-	nukiPublicKey := pubKeyResp.AsPublicKeyCommand().PublicKey()
+	pubKeyCmd, err := pubKeyResp.ParsePublicKeyCommand()
+	if err != nil {
+		return fmt.Errorf("invalid public key response: %w", err)
+	}
+	nukiPublicKey := pubKeyCmd.PublicKey()
 
-	err = c.gdioCom.Send(command.NewPublicKey((*publicKey)[:]))
+	err = gdioCom.Send(command.NewPublicKey((*publicKey)[:]))
 	if err != nil {
 		return fmt.Errorf("error while sending public key to device: %w", err)
 	}
 
-	challenge1, err := c.gdioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
+	challenge1, err := gdioCom.WaitForSpecificResponse(ctx, command.IdChallenge, timeout)
 	if err != nil {
 		return fmt.Errorf("error while waiting for first challenge: %w", err)
 	}
+	challenge1Cmd, err := challenge1.ParseChallengeCommand()
+	if err != nil {
+		return fmt.Errorf("invalid first challenge: %w", err)
+	}
 
-	err = c.gdioCom.Send(command.NewAuthorizationAuthenticator(
-		challenge1.AsChallengeCommand().Nonce(),
+	err = gdioCom.Send(command.NewAuthorizationAuthenticator(
+		challenge1Cmd.Nonce(),
 		nukiPublicKey,
 		(*privateKey)[:],
 		(*publicKey)[:],
@@ -48,86 +70,229 @@ func (c *Client) Pair(ctx context.Context, privateKey, publicKey nacl.Key, id co
 		return fmt.Errorf("error while sending authorization authenticator: %w", err)
 	}
 
-	challenge2, err := c.gdioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
+	challenge2, err := gdioCom.WaitForSpecificResponse(ctx, command.IdChallenge, timeout)
 	if err != nil {
 		return fmt.Errorf("error while waiting for second challenge: %w", err)
 	}
+	challenge2Cmd, err := challenge2.ParseChallengeCommand()
+	if err != nil {
+		return fmt.Errorf("invalid second challenge: %w", err)
+	}
 
-	err = c.gdioCom.Send(command.NewAuthorizationData(
-		challenge2.AsChallengeCommand().Nonce(),
+	authData := command.NewAuthorizationData(
+		challenge2Cmd.Nonce(),
 		nukiPublicKey,
 		(*privateKey)[:],
 		id,
 		idType,
 		name,
-	))
+	)
+	err = gdioCom.Send(authData)
 	if err != nil {
 		return fmt.Errorf("error while seinding authorization data: %w", err)
 	}
 
-	authIdResp, err := c.gdioCom.WaitForSpecificResponse(ctx, command.IdAuthorizationID, c.responseTimeout)
+	authIdResp, err := gdioCom.WaitForSpecificResponse(ctx, command.IdAuthorizationID, timeout)
 	if err != nil {
 		return fmt.Errorf("error while waiting for authorization id: %w", err)
 	}
 
-	//TODO: verify authenticator!
+	authIdCmd, err := authIdResp.ParseAuthorizationIdCommand()
+	if err != nil {
+		return fmt.Errorf("invalid authorization id: %w", err)
+	}
+	if !authIdCmd.VerifyAuthenticator(nukiPublicKey, (*privateKey)[:], command.AuthorizationDataCommand(authData).Nonce()) {
+		//the device does not own the private key of the received public key (man-in-the-middle?)
+		return fmt.Errorf("pairing failed: %w", communication.P_ERROR_BAD_AUTHENTICATOR)
+	}
 
-	err = c.gdioCom.Send(command.NewAuthorizationIdConfirmation(
-		authIdResp.AsAuthorizationIdCommand().Nonce(),
+	err = gdioCom.Send(command.NewAuthorizationIdConfirmation(
+		authIdCmd.Nonce(),
 		nukiPublicKey,
 		(*privateKey)[:],
-		authIdResp.AsAuthorizationIdCommand().AuthorizationId(),
+		authIdCmd.AuthorizationId(),
 	))
 	if err != nil {
 		return fmt.Errorf("error while sending authorization id confirmation: %w", err)
 	}
 
-	status, err := c.gdioCom.WaitForSpecificResponse(ctx, command.IdStatus, c.responseTimeout)
+	status, err := gdioCom.WaitForSpecificResponse(ctx, command.IdStatus, timeout)
 	if err != nil {
 		return fmt.Errorf("error while waiting authorization id confirmation response: %w", err)
 	}
 
-	if !status.AsStatusCommand().IsComplete() {
+	statusCmd, err := status.ParseStatusCommand()
+	if err != nil {
+		return fmt.Errorf("invalid authorization id confirmation response: %w", err)
+	}
+	if !statusCmd.IsComplete() {
 		return fmt.Errorf("pairing failed unexpectedly: status is not completed")
 	}
 
 	//done
-	err = c.Authenticate(privateKey, publicKey, nukiPublicKey, authIdResp.AsAuthorizationIdCommand().AuthorizationId())
+	err = c.authenticate(copyKey(privateKey), copyKey(publicKey), nukiPublicKey, authIdCmd.AuthorizationId())
 	if err != nil {
 		return fmt.Errorf("error while authenticate: %w", err)
 	}
 
+	c.mu.Lock()
+	c.appId = id
+	c.deviceUUID = append([]byte{}, authIdCmd.UUID()...)
+	c.pairedAt = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// PairAndStore will generate a new key-pair, perform the pairing process (see Pair) and save the resulting
+// credentials into the given store. If the pairing was successful but the credentials can not be saved, the
+// credentials are returned together with the error: the device keeps the authorization, so the caller should save
+// the credentials in another way (or retry).
+func (c *Client) PairAndStore(ctx context.Context, store CredentialStore, id command.ClientId, idType command.ClientIdType, name string) (*Credentials, error) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key-pair: %w", err)
+	}
+
+	err = c.Pair(ctx, privateKey, publicKey, id, idType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := c.Credentials()
+	if err := store.Save(credentials); err != nil {
+		return credentials, fmt.Errorf("unable to save credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// AuthenticateFromStore will load the credentials of the connected device from the given store and use them
+// for further communication (see Authenticate). The connection must be established before.
+func (c *Client) AuthenticateFromStore(store CredentialStore) error {
+	c.mu.RLock()
+	address := c.address
+	c.mu.RUnlock()
+
+	if address == nil {
+		return ConnectionNotEstablishedError
+	}
+
+	credentials, err := store.Load(address.String())
+	if err != nil {
+		return fmt.Errorf("unable to load credentials: %w", err)
+	}
+
+	return c.AuthenticateWithCredentials(credentials)
+}
+
+// AuthenticateWithCredentials will use the given credentials for further communication (see Authenticate).
+func (c *Client) AuthenticateWithCredentials(credentials *Credentials) error {
+	err := c.Authenticate(credentials.PrivateKey, credentials.PublicKey, credentials.NukiPublicKey, credentials.AuthId)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.appId = credentials.AppId
+	c.deviceUUID = copyBytes(credentials.DeviceUUID)
+	c.pairedAt = credentials.PairedAt
+	c.mu.Unlock()
+
 	return nil
 }
 
+// Credentials will return the credentials which are used for the communication with the connected device. The keys
+// are copies: so the credentials can be wiped (see Credentials.Zero) without affecting the client.
+func (c *Client) Credentials() *Credentials {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := &Credentials{
+		AuthId:        c.authId,
+		AppId:         c.appId,
+		DeviceUUID:    copyBytes(c.deviceUUID),
+		PrivateKey:    copyKey(c.privateKey),
+		PublicKey:     copyKey(c.publicKey),
+		NukiPublicKey: copyBytes(c.nukiPublicKey),
+		PairedAt:      c.pairedAt,
+	}
+	if c.address != nil {
+		result.DeviceAddress = c.address.String()
+	}
+	if c.gdioCom != nil {
+		result.DeviceType = c.gdioCom.GetDeviceType()
+	}
+
+	return result
+}
+
 // Authenticate will use the given authentication data and use them for further communication to nuki device.
-// The data should be the same which is used for pairing before.
+// The data should be the same which is used for pairing before. The keys will be copied, so the caller can wipe its
+// own copies afterwards.
 func (c *Client) Authenticate(privateKey, publicKey nacl.Key, nukiPublicKey []byte, authId command.AuthorizationId) error {
+	if err := c.queue.acquire(context.Background(), priorityHigh); err != nil {
+		return err
+	}
+	defer c.queue.release()
+
+	return c.authenticate(copyKey(privateKey), copyKey(publicKey), copyBytes(nukiPublicKey), authId)
+}
+
+func (c *Client) authenticate(privateKey, publicKey nacl.Key, nukiPublicKey []byte, authId command.AuthorizationId) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//the shared key will be computed only once (and not for each reconnection)
+	if c.sharedKey == nil || c.privateKey == nil || *c.privateKey != *privateKey || !bytes.Equal(c.nukiPublicKey, nukiPublicKey) {
+		c.sharedKey = command.NewSharedKey((*privateKey)[:], nukiPublicKey)
+	}
+
 	c.privateKey = privateKey
 	c.publicKey = publicKey
 	c.nukiPublicKey = nukiPublicKey
 	c.authId = authId
 
-	var err error
-	c.udioCom, err = communication.NewUserSpecificDataIOCommunicator(
+	if c.client == nil {
+		return ConnectionNotEstablishedError
+	}
+
+	udioCom, err := communication.NewUserSpecificDataIOCommunicatorWithSharedKey(
 		c.client,
 		uint32(authId),
-		(*privateKey)[:],
-		nukiPublicKey,
+		c.sharedKey,
 	)
 	if err != nil {
 		return err
 	}
+	c.udioCom = udioCom
+	c.completionPending = false
+	c.events.forward(c.udioCom)
 
 	return nil
 }
 
 // AuthenticationId will return the authId which was generated after the pairing process. See Pair.
 func (c *Client) AuthenticationId() command.AuthorizationId {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.authId
 }
 
+// DeviceUUID will return the uuid of the device which was received while pairing. See Pair.
+// It is nil if the client was authenticated without credentials which contain the uuid.
+func (c *Client) DeviceUUID() []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return copyBytes(c.deviceUUID)
+}
+
 // PublicKey will return the public key of the connected nuki device.
 func (c *Client) PublicKey() []byte {
-	return c.nukiPublicKey
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return copyBytes(c.nukiPublicKey)
 }
@@ -0,0 +1,200 @@
+package nuki
+
+import (
+	"bytes"
+	"context"
+	"github.com/tarent/go-nuki/communication/command"
+	"sync"
+	"time"
+)
+
+// DefaultStatesCacheTTL is a reasonable time to live of cached states for dashboards and similar polling consumers.
+const DefaultStatesCacheTTL = 10 * time.Second
+
+// stateCache holds the last known states and config of the device. The config is only valid as long as the config
+// update count of the states does not change.
+type stateCache struct {
+	statesTTL time.Duration
+	now       func() time.Time
+
+	mu         sync.Mutex
+	states     command.StatesCommand
+	receivedAt time.Time
+	config     command.ConfigCommand
+	// configUpdateCount is the config update count of the states which were known while the config was read
+	configUpdateCount uint8
+}
+
+// WithCache enables the caching of states and config (see CachedStates and CachedConfig). States will be cached for
+// the given time to live. The config will be cached until the config update count of the states changes. All states
+// which are received (requested or pushed) will update the cache. If a cached value changes, an event of the type
+// EventTypeStatesChanged or EventTypeConfigChanged will be published (see Subscribe).
+func (c *Client) WithCache(statesTTL time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache = &stateCache{
+		statesTTL: statesTTL,
+		now:       time.Now,
+	}
+	return c
+}
+
+func (c *Client) stateCache() *stateCache {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cache
+}
+
+// CachedStates returns the cached states if they are younger than the time to live (see WithCache). Otherwise, the
+// states will be requested from the device (see ReadStates). Without cache, the states will always be requested.
+func (c *Client) CachedStates(ctx context.Context, opts ...CallOption) (command.StatesCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	if states := c.stateCache().freshStates(); states != nil {
+		return states, nil
+	}
+	return c.ReadStates(ctx)
+}
+
+// CachedConfig returns the cached config as long as the config update count of the (cached) states did not change
+// since the config was read. Otherwise, the config will be requested from the device (see ReadConfig). Without cache,
+// the config will always be requested.
+func (c *Client) CachedConfig(ctx context.Context, opts ...CallOption) (command.ConfigCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	cache := c.stateCache()
+	if cache == nil {
+		return c.ReadConfig(ctx)
+	}
+
+	states, err := c.CachedStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config := cache.validConfig(states.ConfigUpdateCount()); config != nil {
+		return config, nil
+	}
+
+	config, err := c.ReadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.storeConfig(config, states.ConfigUpdateCount())
+	return config, nil
+}
+
+// InvalidateCache removes all cached values. So the next call of CachedStates and CachedConfig will request them
+// from the device.
+func (c *Client) InvalidateCache() {
+	c.stateCache().invalidate()
+}
+
+// storeStates updates the cache (if enabled) with the given states and publishes a change event if necessary.
+func (c *Client) storeStates(states command.StatesCommand) {
+	if c.stateCache().storeStates(states) {
+		c.events.publish(Event{
+			Type:       EventTypeStatesChanged,
+			ReceivedAt: time.Now(),
+			DeviceType: c.GetDeviceType(),
+			Command:    command.Command(states),
+		})
+	}
+}
+
+// storeConfig updates the cache (if enabled) with the given config and publishes a change event if necessary.
+func (c *Client) storeConfig(config command.ConfigCommand, configUpdateCount uint8) {
+	if c.stateCache().storeConfig(config, configUpdateCount) {
+		c.events.publish(Event{
+			Type:       EventTypeConfigChanged,
+			ReceivedAt: time.Now(),
+			DeviceType: c.GetDeviceType(),
+			Command:    command.Command(config),
+		})
+	}
+}
+
+func (s *stateCache) freshStates() command.StatesCommand {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states == nil || s.now().Sub(s.receivedAt) >= s.statesTTL {
+		return nil
+	}
+	return s.states
+}
+
+func (s *stateCache) validConfig(configUpdateCount uint8) command.ConfigCommand {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config == nil || s.configUpdateCount != configUpdateCount {
+		return nil
+	}
+	return s.config
+}
+
+// storeStates returns true if the given states differ from the previously cached ones.
+func (s *stateCache) storeStates(states command.StatesCommand) bool {
+	if s == nil || states == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := s.states != nil && !bytes.Equal(statesWithoutTime(s.states), statesWithoutTime(states))
+	s.states = states
+	s.receivedAt = s.now()
+	return changed
+}
+
+// storeConfig returns true if the given config differs from the previously cached one.
+func (s *stateCache) storeConfig(config command.ConfigCommand, configUpdateCount uint8) bool {
+	if s == nil || config == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := s.config != nil && !bytes.Equal(configWithoutTime(s.config), configWithoutTime(config))
+	s.config = config
+	s.configUpdateCount = configUpdateCount
+	return changed
+}
+
+func (s *stateCache) invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states = nil
+	s.config = nil
+}
+
+// statesWithoutTime returns the payload of the states without the current time (which changes every second).
+func statesWithoutTime(states command.StatesCommand) []byte {
+	payload := append([]byte{}, command.Command(states).Payload()...)
+	copy(payload[3:10], make([]byte, 7))
+	return payload
+}
+
+// configWithoutTime returns the payload of the config without the current time (which changes every second).
+func configWithoutTime(config command.ConfigCommand) []byte {
+	payload := append([]byte{}, command.Command(config).Payload()...)
+	switch config.Type() {
+	case command.ConfigTypeSmartLock:
+		copy(payload[49:56], make([]byte, 7))
+	case command.ConfigTypeOpener:
+		copy(payload[48:55], make([]byte, 7))
+	}
+	return payload
+}
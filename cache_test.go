@@ -0,0 +1,169 @@
+package nuki
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+// cachedDevice answers states and config requests with the current values of the device.
+type cachedDevice struct {
+	*scriptedCommunicator
+
+	lockState         command.LockState
+	configUpdateCount uint8
+	deviceName        string
+}
+
+func newCachedDevice() *cachedDevice {
+	device := &cachedDevice{lockState: command.LockStateLocked, deviceName: "Front door"}
+	device.scriptedCommunicator = &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdStates):
+				payload := testStates(device.lockState, command.LockActionCompletionStatusSuccess).Payload()
+				payload[13] = device.configUpdateCount
+				return []command.Command{command.NewCommand(command.IdStates, payload)}
+			case cmd.Is(command.IdRequestConfig):
+				payload := make([]byte, 74)
+				copy(payload[4:36], device.deviceName)
+				return []command.Command{command.NewCommand(command.IdConfig, payload)}
+			}
+			return nil
+		},
+	}
+	return device
+}
+
+func (d *cachedDevice) requests(id command.Id) int {
+	count := 0
+	for _, cmd := range d.sent {
+		if cmd.Is(id) || cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(id) {
+			count++
+		}
+	}
+	return count
+}
+
+func cachedTestClient(device *cachedDevice, ttl time.Duration) (*Client, *time.Time) {
+	now := time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC)
+	client := connectedTestClient(device).WithCache(ttl)
+	client.cache.now = func() time.Time {
+		return now
+	}
+	return client, &now
+}
+
+func TestClient_CachedStates(t *testing.T) {
+	device := newCachedDevice()
+	toTest, now := cachedTestClient(device, 10*time.Second)
+
+	for i := 0; i < 3; i++ {
+		states, err := toTest.CachedStates(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, command.LockStateLocked, states.LockState())
+	}
+	assert.Equal(t, 1, device.requests(command.IdStates))
+
+	*now = now.Add(10 * time.Second)
+	device.lockState = command.LockStateSmartLockUnlocked
+
+	states, err := toTest.CachedStates(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, command.LockStateSmartLockUnlocked, states.LockState())
+	assert.Equal(t, 2, device.requests(command.IdStates))
+}
+
+func TestClient_CachedConfig(t *testing.T) {
+	device := newCachedDevice()
+	toTest, now := cachedTestClient(device, 10*time.Second)
+
+	for i := 0; i < 3; i++ {
+		config, err := toTest.CachedConfig(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "Front door", config.Name()[:10])
+
+		//the states expire but the config update count stays the same
+		*now = now.Add(time.Minute)
+	}
+	assert.Equal(t, 3, device.requests(command.IdStates))
+	assert.Equal(t, 1, device.requests(command.IdRequestConfig))
+
+	device.configUpdateCount++
+	device.deviceName = "Back door"
+
+	config, err := toTest.CachedConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Back door", config.Name()[:9])
+	assert.Equal(t, 2, device.requests(command.IdRequestConfig))
+}
+
+func TestClient_CachedConfig_WithoutCache(t *testing.T) {
+	device := newCachedDevice()
+	toTest := connectedTestClient(device)
+
+	for i := 0; i < 2; i++ {
+		_, err := toTest.CachedConfig(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 0, device.requests(command.IdStates))
+	assert.Equal(t, 2, device.requests(command.IdRequestConfig))
+}
+
+func TestClient_Cache_ChangeEvents(t *testing.T) {
+	device := newCachedDevice()
+	toTest, now := cachedTestClient(device, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := toTest.Subscribe(ctx)
+
+	_, err := toTest.CachedConfig(ctx)
+	assert.NoError(t, err)
+
+	//same values do not lead to a change
+	*now = now.Add(time.Second)
+	_, err = toTest.CachedConfig(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	*now = now.Add(time.Second)
+	device.lockState = command.LockStateSmartLockUnlocked
+	device.configUpdateCount++
+	device.deviceName = "Back door"
+	_, err = toTest.CachedConfig(ctx)
+	assert.NoError(t, err)
+
+	e := <-events
+	assert.Equal(t, EventTypeStatesChanged, e.Type)
+	assert.Equal(t, command.LockStateSmartLockUnlocked, e.States().LockState())
+
+	e = <-events
+	assert.Equal(t, EventTypeConfigChanged, e.Type)
+	assert.Equal(t, "Back door", e.Config().Name()[:9])
+}
+
+func TestClient_Cache_PushedStates(t *testing.T) {
+	device := newCachedDevice()
+	toTest, _ := cachedTestClient(device, time.Minute)
+
+	_, err := toTest.CachedStates(context.Background())
+	assert.NoError(t, err)
+
+	//pushed by the device (see eventHub.forward)
+	toTest.events.onStates(testStates(command.LockStateSmartLockUnlocking, command.LockActionCompletionStatusSuccess).AsStatesCommand())
+
+	states, err := toTest.CachedStates(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, command.LockStateSmartLockUnlocking, states.LockState())
+	assert.Equal(t, 1, device.requests(command.IdStates))
+
+	toTest.InvalidateCache()
+	_, err = toTest.CachedStates(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, device.requests(command.IdStates))
+}
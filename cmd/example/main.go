@@ -2,13 +2,10 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/go-ble/ble/linux"
-	"github.com/kevinburke/nacl/box"
 	"github.com/go-ble/ble"
+	"github.com/go-ble/ble/linux"
 	"github.com/tarent/go-nuki"
 	"github.com/tarent/go-nuki/communication/command"
 	"github.com/tarent/go-nuki/logger"
@@ -24,6 +21,11 @@ func main() {
 	// disable debug logging
 	logger.Debug = nil
 
+	store, err := nuki.NewFileCredentialStore("credentials")
+	if err != nil {
+		panic(err)
+	}
+
 	nukiClient := nuki.NewClient(device)
 	defer nukiClient.Close()
 
@@ -33,27 +35,20 @@ func main() {
 		panic(err)
 	}
 
-	//generate key-pair
-	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
-	if err != nil {
-		panic(err)
-	}
+	err = nukiClient.AuthenticateFromStore(store)
+	if errors.Is(err, nuki.CredentialsNotFoundError) {
+		//not paired yet: the generated key-pair and the authentication id will be saved into the store
+		credentials, err := nukiClient.PairAndStore(context.Background(), store, 13, command.ClientIdTypeApp, "Go-Nuki-Example")
+		if err != nil {
+			panic(err)
+		}
 
-	err = nukiClient.Pair(context.Background(), privateKey, publicKey, 13, command.ClientIdTypeApp, "Go-Nuki-Example")
-	if err != nil {
+		fmt.Printf("Paired with authentication id: %d\n", credentials.AuthId)
+	} else if err != nil {
+		//for example a corrupted credential file: pairing again would waste an authorization of the device
 		panic(err)
 	}
 
-	//after pairing was successful, save the information into a file or similar
-	toSave, _ := json.Marshal(map[string]interface{}{
-		"authId":     nukiClient.AuthenticationId(),
-		"privKey":    hex.EncodeToString((*privateKey)[:]),
-		"pubKey":     hex.EncodeToString((*publicKey)[:]),
-		"nukiPubKey": hex.EncodeToString(nukiClient.PublicKey()),
-	})
-
-	fmt.Printf("Save content: %s\n", toSave)
-
 	states, err := nukiClient.ReadStates(context.Background())
 	if err != nil {
 		panic(err)
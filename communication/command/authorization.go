@@ -38,6 +38,16 @@ func NewAuthorizationAuthenticator(
 	return NewCommand(IdAuthorizationAuthenticator, hash.Sum(nil))
 }
 
+// AuthorizationDataCommand is the authorization data which is sent by the client while pairing (see
+// NewAuthorizationData).
+type AuthorizationDataCommand Command
+
+// Nonce returns the nonce which was generated by the client. The device includes it into the authenticator of the
+// authorization-id (see AuthorizationIdCommand.VerifyAuthenticator).
+func (a AuthorizationDataCommand) Nonce() []byte {
+	return Command(a).bytesFrom(32 + 1 + 4 + 32)
+}
+
 func NewAuthorizationData(
 	nukiNonce []byte, nukiPubKey []byte,
 	privateKey []byte,
@@ -1,29 +1,82 @@
 package command
 
-import "encoding/binary"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+)
 
 type AuthorizationIdCommand Command
 
+// AsAuthorizationIdCommand returns the command as AuthorizationIdCommand. Returns nil if the command is not a valid AuthorizationIdCommand (see ParseAuthorizationIdCommand).
 func (c Command) AsAuthorizationIdCommand() AuthorizationIdCommand {
-	if !c.Is(IdAuthorizationID) {
+	result, err := c.ParseAuthorizationIdCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseAuthorizationIdCommand returns the command as AuthorizationIdCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseAuthorizationIdCommand() (AuthorizationIdCommand, error) {
+	if err := c.parse(IdAuthorizationID); err != nil {
+		return nil, err
+	}
+	return AuthorizationIdCommand(c), nil
+}
 
-	return AuthorizationIdCommand(c)
+// Validate checks if the command is a valid AuthorizationIdCommand.
+func (a AuthorizationIdCommand) Validate() error {
+	return Command(a).parse(IdAuthorizationID)
 }
 
 func (a AuthorizationIdCommand) Authenticator() []byte {
-	return Command(a).Payload()[:32]
+	return Command(a).bytesAt(0, 32)
 }
 
 func (a AuthorizationIdCommand) AuthorizationId() AuthorizationId {
-	return AuthorizationId(binary.LittleEndian.Uint32(Command(a).Payload()[32:36]))
+	return AuthorizationId(binary.LittleEndian.Uint32(Command(a).bytesAt(32, 36)))
 }
 
 func (a AuthorizationIdCommand) UUID() []byte {
-	return Command(a).Payload()[36:52]
+	return Command(a).bytesAt(36, 52)
 }
 
 func (a AuthorizationIdCommand) Nonce() []byte {
-	return Command(a).Payload()[52:]
+	return Command(a).bytesFrom(52)
+}
+
+// VerifyAuthenticator checks if the authenticator was calculated by the owner of the given nuki public key.
+// The authenticator is the HMAC-SHA256 (with the shared key) over the authorization-id, the uuid, the nonce of the
+// device and the nonce of the client which was sent with the authorization data (see AuthorizationDataCommand.Nonce).
+func (a AuthorizationIdCommand) VerifyAuthenticator(nukiPubKey []byte, privateKey []byte, clientNonce []byte) bool {
+	sharedKey := box.Precompute(nacl.Key(nukiPubKey), nacl.Key(privateKey))
+
+	hash := hmac.New(sha256.New, (*sharedKey)[:])
+	hash.Write(Command(a).bytesFrom(32))
+	hash.Write(clientNonce)
+
+	return hmac.Equal(hash.Sum(nil), a.Authenticator())
+}
+
+func (a AuthorizationIdCommand) Id() Id {
+	return IdAuthorizationID
+}
+
+func (a AuthorizationIdCommand) String() string {
+	return fmt.Sprintf("Authorization-ID: %d; UUID: %x", a.AuthorizationId(), a.UUID())
+}
+
+func (a AuthorizationIdCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id              Id              `json:"id"`
+		Name            string          `json:"name"`
+		AuthorizationId AuthorizationId `json:"authorizationId"`
+		UUID            string          `json:"uuid"`
+	}{a.Id(), a.Id().Name(), a.AuthorizationId(), hex.EncodeToString(a.UUID())})
 }
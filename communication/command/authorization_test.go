@@ -50,3 +50,52 @@ func TestNewAuthorizationIdConfirmation(t *testing.T) {
 
 	assert.Equal(t, "1E003A41B91A66FBC4D22EFEFBB7272140829695A3917433D5BEB981B76166D13F8A02000000CDF5", strings.ToUpper(hex.EncodeToString(result)))
 }
+
+func TestAuthorizationIdCommand_VerifyAuthenticator(t *testing.T) {
+	//keys and nonces are the example values of the nuki api documentation: the nonce of the device is the one which
+	//is confirmed in the example of the authorization-id confirmation, the nonce of the client is the one of the
+	//example authorization data. The example has no uuid, so the expected authenticator was calculated independently
+	//(python implementation of x25519/hsalsa20, which reproduces the example shared key 217FCB0F...) over
+	//authorization-id | uuid | device nonce | client nonce.
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	clientNonce, _ := hex.DecodeString("52AFE0A664B4E9B56DC6BD4CB718A6C9FED6BE17A7411072AA0D315378140577")
+	payload, _ := hex.DecodeString("C002F7E610FDA7D81AE78F8B0949B3DCD041A62685A50CBEE697133861D838A3" +
+		"02000000" +
+		"3F2A1C2B8E0D4F6A9B7C5D3E1F2A4B6C" +
+		"EA479915982F13C61D997A56678AD77791BFA7E95229A3DD34F87132BF3E3C97")
+
+	toTest := NewCommand(IdAuthorizationID, payload).AsAuthorizationIdCommand()
+
+	assert.True(t, toTest.VerifyAuthenticator(nukiPubKey, privKey, clientNonce))
+	assert.Equal(t, AuthorizationId(2), toTest.AuthorizationId())
+	assert.Equal(t, "3f2a1c2b8e0d4f6a9b7c5d3e1f2a4b6c", hex.EncodeToString(toTest.UUID()))
+
+	//without the nonce of the client
+	assert.False(t, toTest.VerifyAuthenticator(nukiPubKey, privKey, nil))
+
+	//another nonce of the client
+	assert.False(t, toTest.VerifyAuthenticator(nukiPubKey, privKey, make([]byte, 32)))
+
+	//manipulated authorization id
+	manipulated := NewCommand(IdAuthorizationID, append([]byte{}, payload...))
+	manipulated.Payload()[32] = 0x03
+	assert.False(t, manipulated.AsAuthorizationIdCommand().VerifyAuthenticator(nukiPubKey, privKey, clientNonce))
+
+	//another key
+	otherKey, _ := hex.DecodeString("F88127CCF48023B5CBE9101D24BAA8A368DA94E8C2E3CDE2DED29CE96AB50C15")
+	assert.False(t, toTest.VerifyAuthenticator(otherKey, privKey, clientNonce))
+}
+
+func TestAuthorizationDataCommand_Nonce(t *testing.T) {
+	nukiNonce, _ := hex.DecodeString("E0742CFEA39CB46109385BF91286A3C02F40EE86B0B62FC34033094DE41E2C0D")
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+
+	first := NewAuthorizationData(nukiNonce, nukiPubKey, privKey, 0, ClientIdTypeApp, "Marc (Test)")
+	second := NewAuthorizationData(nukiNonce, nukiPubKey, privKey, 0, ClientIdTypeApp, "Marc (Test)")
+
+	assert.Len(t, AuthorizationDataCommand(first).Nonce(), 32)
+	assert.Equal(t, first.Payload()[len(first.Payload())-32:], AuthorizationDataCommand(first).Nonce())
+	assert.NotEqual(t, AuthorizationDataCommand(first).Nonce(), AuthorizationDataCommand(second).Nonce())
+}
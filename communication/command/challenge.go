@@ -1,15 +1,51 @@
 package command
 
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
 type ChallengeCommand Command
 
+// AsChallengeCommand returns the command as ChallengeCommand. Returns nil if the command is not a valid ChallengeCommand (see ParseChallengeCommand).
 func (c Command) AsChallengeCommand() ChallengeCommand {
-	if !c.Is(IdChallenge) {
+	result, err := c.ParseChallengeCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseChallengeCommand returns the command as ChallengeCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseChallengeCommand() (ChallengeCommand, error) {
+	if err := c.parse(IdChallenge); err != nil {
+		return nil, err
+	}
+	return ChallengeCommand(c), nil
+}
 
-	return ChallengeCommand(c)
+// Validate checks if the command is a valid ChallengeCommand.
+func (c ChallengeCommand) Validate() error {
+	return Command(c).parse(IdChallenge)
 }
 
 func (c ChallengeCommand) Nonce() []byte {
 	return Command(c).Payload()
 }
+
+func (c ChallengeCommand) Id() Id {
+	return IdChallenge
+}
+
+func (c ChallengeCommand) String() string {
+	return fmt.Sprintf("Nonce: %x", c.Nonce())
+}
+
+func (c ChallengeCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id    Id     `json:"id"`
+		Name  string `json:"name"`
+		Nonce string `json:"nonce"`
+	}{c.Id(), c.Id().Name(), hex.EncodeToString(c.Nonce())})
+}
@@ -2,8 +2,10 @@ package command
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 )
 
@@ -78,12 +80,55 @@ func NewRequestConfig(nonce []byte) Command {
 	return NewCommand(IdRequestConfig, nonce)
 }
 
+// NewSetConfig builds a command which applies the given config (for example a config which was modified by
+// WithTimeZone). Fields which can not be set (for example the firmware version) are left out. Returns nil if the type
+// of the config is unknown.
+func NewSetConfig(config ConfigCommand, pin Pin, nonce []byte) Command {
+	payload := Command(config).Payload()
+
+	var parts [][]byte
+	switch config.Type() {
+	case ConfigTypeSmartLock:
+		//name ... led brightness, timezone offset + dst mode, fob actions + single lock + advertising mode, timezone id
+		parts = [][]byte{payload[4:49], payload[56:59], payload[60:65], payload[72:74]}
+	case ConfigTypeOpener:
+		//name ... longitude, (without capabilities) pairing enabled ... led flash enabled, timezone offset + dst mode,
+		//fob actions + operation mode + advertising mode, timezone id
+		parts = [][]byte{payload[4:44], payload[45:48], payload[55:58], payload[59:64], payload[70:72]}
+	default:
+		return nil
+	}
+
+	result := make([]byte, 0, 55+len(nonce)+2)
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	result = append(result, nonce...)
+	result = append(result, pin.AsByte()...)
+
+	return NewCommand(IdSetConfig, result)
+}
+
+// AsConfigCommand returns the command as ConfigCommand. Returns nil if the command is not a valid ConfigCommand (see ParseConfigCommand).
 func (c Command) AsConfigCommand() ConfigCommand {
-	if !c.Is(IdConfig) {
+	result, err := c.ParseConfigCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
 
-	return ConfigCommand(c)
+// ParseConfigCommand returns the command as ConfigCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseConfigCommand() (ConfigCommand, error) {
+	if err := c.parse(IdConfig); err != nil {
+		return nil, err
+	}
+	return ConfigCommand(c), nil
+}
+
+// Validate checks if the command is a valid ConfigCommand.
+func (c ConfigCommand) Validate() error {
+	return Command(c).parse(IdConfig)
 }
 
 func (c ConfigCommand) AsSmartLockConfig() ConfigSmartLockCommand {
@@ -110,70 +155,70 @@ func (c ConfigCommand) Type() ConfigType {
 }
 
 func (c ConfigCommand) NukiId() uint32 {
-	return binary.LittleEndian.Uint32(Command(c).Payload()[0:4])
+	return binary.LittleEndian.Uint32(Command(c).bytesAt(0, 4))
 }
 
 func (c ConfigCommand) Name() string {
-	return string(Command(c).Payload()[4:36])
+	return string(Command(c).bytesAt(4, 36))
 }
 
 func (c ConfigCommand) Latitude() float32 {
 	return math.Float32frombits(
-		binary.LittleEndian.Uint32(Command(c).Payload()[36:40]),
+		binary.LittleEndian.Uint32(Command(c).bytesAt(36, 40)),
 	)
 }
 
 func (c ConfigCommand) Longitude() float32 {
 	return math.Float32frombits(
-		binary.LittleEndian.Uint32(Command(c).Payload()[40:44]),
+		binary.LittleEndian.Uint32(Command(c).bytesAt(40, 44)),
 	)
 }
 
 func (c ConfigOpenerCommand) Capabilities() OpenerCapabilities {
-	return OpenerCapabilities(Command(c).Payload()[44])
+	return OpenerCapabilities(Command(c).byteAt(44))
 }
 
 func (c ConfigSmartLockCommand) AutoUnlatch() bool {
-	return Command(c).Payload()[44] != 0
+	return Command(c).byteAt(44) != 0
 }
 
 func (c ConfigCommand) PairingEnabled() bool {
-	return Command(c).Payload()[45] != 0
+	return Command(c).byteAt(45) != 0
 }
 
 func (c ConfigCommand) ButtonEnabled() bool {
-	return Command(c).Payload()[46] != 0
+	return Command(c).byteAt(46) != 0
 }
 
 func (c ConfigCommand) LEDEnabled() bool {
-	return Command(c).Payload()[47] != 0
+	return Command(c).byteAt(47) != 0
 }
 
 func (c ConfigSmartLockCommand) LEDBrightness() uint8 {
-	return Command(c).Payload()[48]
+	return Command(c).byteAt(48)
 }
 
 func (c ConfigCommand) CurrentTimeAsUTC() time.Time {
 	var result time.Time
 	if c.Type() == ConfigTypeSmartLock {
 		result = time.Date(
-			int(binary.LittleEndian.Uint16(Command(c).Payload()[49:51])),
-			time.Month(Command(c).Payload()[51]),
-			int(Command(c).Payload()[52]),
-			int(Command(c).Payload()[53]),
-			int(Command(c).Payload()[54]),
-			int(Command(c).Payload()[55]),
+			int(binary.LittleEndian.Uint16(Command(c).bytesAt(49, 51))),
+			time.Month(Command(c).byteAt(51)),
+			int(Command(c).byteAt(52)),
+			int(Command(c).byteAt(53)),
+			int(Command(c).byteAt(54)),
+			int(Command(c).byteAt(55)),
 			0,
 			time.UTC,
 		)
 	} else if c.Type() == ConfigTypeOpener {
 		result = time.Date(
-			int(binary.LittleEndian.Uint16(Command(c).Payload()[48:50])),
-			time.Month(Command(c).Payload()[50]),
-			int(Command(c).Payload()[51]),
-			int(Command(c).Payload()[52]),
-			int(Command(c).Payload()[53]),
-			int(Command(c).Payload()[54]),
+			int(binary.LittleEndian.Uint16(Command(c).bytesAt(48, 50))),
+			time.Month(Command(c).byteAt(50)),
+			int(Command(c).byteAt(51)),
+			int(Command(c).byteAt(52)),
+			int(Command(c).byteAt(53)),
+			int(Command(c).byteAt(54)),
 			0,
 			time.UTC,
 		)
@@ -182,12 +227,63 @@ func (c ConfigCommand) CurrentTimeAsUTC() time.Time {
 	return result
 }
 
+// CurrentTime returns the current time of the device in its location (see Location).
+func (c ConfigCommand) CurrentTime() time.Time {
+	return c.CurrentTimeAsUTC().In(c.Location())
+}
+
+// Location returns the location of the configured time zone. If no (known) time zone is configured, a fixed zone with
+// the timezone offset will be returned.
+func (c ConfigCommand) Location() *time.Location {
+	if location, err := c.TimeZoneId().Location(); err == nil {
+		return location
+	}
+	return time.FixedZone("nuki", int(c.TimezoneOffset().Seconds()))
+}
+
+// timeZoneIndexes returns the payload indexes of the timezone offset, dst mode and time zone id. Returns false if the
+// type of the config is unknown.
+func (c ConfigCommand) timeZoneIndexes() (offset, dstMode, timeZoneId int, ok bool) {
+	switch c.Type() {
+	case ConfigTypeSmartLock:
+		return 56, 58, 72, true
+	case ConfigTypeOpener:
+		return 55, 57, 70, true
+	}
+	return 0, 0, 0, false
+}
+
+// WithTimeZone returns a copy of the config whose time zone id, timezone offset and dst mode are set to the given
+// location (see TimeZoneIdOf). The timezone offset is the offset without daylight saving time. The dst mode can only
+// express the european rules, so it will be disabled for all other locations. Returns nil if the type of the config
+// is unknown.
+func (c ConfigCommand) WithTimeZone(location *time.Location) ConfigCommand {
+	offsetIndex, dstIndex, idIndex, ok := c.timeZoneIndexes()
+	if !ok {
+		return nil
+	}
+
+	id, _ := TimeZoneIdOf(location)
+	offset, dst := standardOffset(location, time.Now().Year())
+	dstMode := DaylightSavingTimeModeDisabled
+	if dst && strings.HasPrefix(id.Name(), "Europe/") {
+		dstMode = DaylightSavingTimeModeEuropean
+	}
+
+	payload := append([]byte{}, Command(c).Payload()...)
+	binary.LittleEndian.PutUint16(payload[offsetIndex:offsetIndex+2], uint16(int16(offset/time.Minute)))
+	payload[dstIndex] = uint8(dstMode)
+	binary.LittleEndian.PutUint16(payload[idIndex:idIndex+2], uint16(id))
+
+	return ConfigCommand(NewCommand(IdConfig, payload))
+}
+
 func (c ConfigCommand) TimezoneOffset() time.Duration {
 	var offsetInMin uint16
 	if c.Type() == ConfigTypeSmartLock {
-		offsetInMin = binary.LittleEndian.Uint16(Command(c).Payload()[56:58])
+		offsetInMin = binary.LittleEndian.Uint16(Command(c).bytesAt(56, 58))
 	} else if c.Type() == ConfigTypeOpener {
-		offsetInMin = binary.LittleEndian.Uint16(Command(c).Payload()[55:57])
+		offsetInMin = binary.LittleEndian.Uint16(Command(c).bytesAt(55, 57))
 	}
 
 	return time.Duration(int16(offsetInMin)) * time.Minute
@@ -195,9 +291,9 @@ func (c ConfigCommand) TimezoneOffset() time.Duration {
 
 func (c ConfigCommand) DSTMode() DaylightSavingTimeMode {
 	if c.Type() == ConfigTypeSmartLock {
-		return DaylightSavingTimeMode(Command(c).Payload()[58])
+		return DaylightSavingTimeMode(Command(c).byteAt(58))
 	} else if c.Type() == ConfigTypeOpener {
-		return DaylightSavingTimeMode(Command(c).Payload()[57])
+		return DaylightSavingTimeMode(Command(c).byteAt(57))
 	}
 
 	return DaylightSavingTimeModeUnknown
@@ -205,9 +301,9 @@ func (c ConfigCommand) DSTMode() DaylightSavingTimeMode {
 
 func (c ConfigCommand) HasFob() bool {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[59] != 0x00
+		return Command(c).byteAt(59) != 0x00
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[58] != 0x00
+		return Command(c).byteAt(58) != 0x00
 	}
 
 	return false
@@ -215,9 +311,9 @@ func (c ConfigCommand) HasFob() bool {
 
 func (c ConfigCommand) FobAction1() uint8 {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[60]
+		return Command(c).byteAt(60)
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[59]
+		return Command(c).byteAt(59)
 	}
 
 	return 0x00
@@ -225,9 +321,9 @@ func (c ConfigCommand) FobAction1() uint8 {
 
 func (c ConfigCommand) FobAction2() uint8 {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[61]
+		return Command(c).byteAt(61)
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[60]
+		return Command(c).byteAt(60)
 	}
 
 	return 0x00
@@ -235,27 +331,27 @@ func (c ConfigCommand) FobAction2() uint8 {
 
 func (c ConfigCommand) FobAction3() uint8 {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[62]
+		return Command(c).byteAt(62)
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[61]
+		return Command(c).byteAt(61)
 	}
 
 	return 0x00
 }
 
 func (c ConfigOpenerCommand) OperationMode() OpenerOperationMode {
-	return OpenerOperationMode(Command(c).Payload()[62])
+	return OpenerOperationMode(Command(c).byteAt(62))
 }
 
 func (c ConfigSmartLockCommand) SingleLock() bool {
-	return Command(c).Payload()[63] != 0x00
+	return Command(c).byteAt(63) != 0x00
 }
 
 func (c ConfigCommand) AdvertisingMode() AdvertisingMode {
 	if c.Type() == ConfigTypeSmartLock {
-		return AdvertisingMode(Command(c).Payload()[64])
+		return AdvertisingMode(Command(c).byteAt(64))
 	} else if c.Type() == ConfigTypeOpener {
-		return AdvertisingMode(Command(c).Payload()[63])
+		return AdvertisingMode(Command(c).byteAt(63))
 	}
 
 	return 0x00
@@ -263,9 +359,9 @@ func (c ConfigCommand) AdvertisingMode() AdvertisingMode {
 
 func (c ConfigCommand) HasKeypad() bool {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[65] != 0x00
+		return Command(c).byteAt(65) != 0x00
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[64] != 0x00
+		return Command(c).byteAt(64) != 0x00
 	}
 
 	return false
@@ -273,9 +369,9 @@ func (c ConfigCommand) HasKeypad() bool {
 
 func (c ConfigCommand) FirmwareVersion() Version {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[66:69]
+		return Command(c).bytesAt(66, 69)
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[65:68]
+		return Command(c).bytesAt(65, 68)
 	}
 
 	return nil
@@ -283,31 +379,31 @@ func (c ConfigCommand) FirmwareVersion() Version {
 
 func (c ConfigCommand) HardwareRevision() Version {
 	if c.Type() == ConfigTypeSmartLock {
-		return Command(c).Payload()[69:71]
+		return Command(c).bytesAt(69, 71)
 	} else if c.Type() == ConfigTypeOpener {
-		return Command(c).Payload()[68:70]
+		return Command(c).bytesAt(68, 70)
 	}
 
 	return nil
 }
 
 func (c ConfigSmartLockCommand) HomeKitStatus() HomeKitStatus {
-	return HomeKitStatus(Command(c).Payload()[71])
+	return HomeKitStatus(Command(c).byteAt(71))
 }
 
 func (c ConfigCommand) TimeZoneId() TimeZoneId {
 	tz := TimeZoneId(0xFFFF)
 	if c.Type() == ConfigTypeSmartLock {
-		tz = TimeZoneId(binary.LittleEndian.Uint16(Command(c).Payload()[72:74]))
+		tz = TimeZoneId(binary.LittleEndian.Uint16(Command(c).bytesAt(72, 74)))
 	} else if c.Type() == ConfigTypeOpener {
-		tz = TimeZoneId(binary.LittleEndian.Uint16(Command(c).Payload()[70:72]))
+		tz = TimeZoneId(binary.LittleEndian.Uint16(Command(c).bytesAt(70, 72)))
 	}
 
 	return tz
 }
 
 func (c ConfigSmartLockCommand) String() string {
-	return fmt.Sprintf("Auto unlatch: %v\nLED brightness: %d\nSingle lock: %v\nHomeKit status: 0x%02x",
+	return fmt.Sprintf("Auto unlatch: %v\nLED brightness: %d\nSingle lock: %v\nHomeKit status: %s",
 		c.AutoUnlatch(),
 		c.LEDBrightness(),
 		c.SingleLock(),
@@ -316,7 +412,7 @@ func (c ConfigSmartLockCommand) String() string {
 }
 
 func (c ConfigOpenerCommand) String() string {
-	return fmt.Sprintf("Capabilities: 0x%02x\nOperation mode: 0x%02x",
+	return fmt.Sprintf("Capabilities: %s\nOperation mode: %s",
 		c.Capabilities(),
 		c.OperationMode(),
 	)
@@ -331,9 +427,9 @@ func (c ConfigCommand) String() string {
 	}
 
 	return fmt.Sprintf("Nuki-ID: %08x\nName: %s\nLatitude: %f\nLongitude: %f\n"+
-		"Pairing enabled: %v\nButton enabled: %v\nLED enabled: %v\nCurrent Time (UTC): %s, TZ-Offset: %s\nDST-Mode: 0x%02x\n"+
+		"Pairing enabled: %v\nButton enabled: %v\nLED enabled: %v\nCurrent Time (UTC): %s, TZ-Offset: %s\nDST-Mode: %s\n"+
 		"Has Fob: %v\nFob Action#1: 0x%02x\nFob Action#2: 0x%02x\nFob Action#3: 0x%02x\n"+
-		"Advertising mode: 0x%02x\nHas Keypad: %v\nFirmware: %s\nHardware: %s\nTimezone: %s\n"+
+		"Advertising mode: %s\nHas Keypad: %v\nFirmware: %s\nHardware: %s\nTimezone: %s\n"+
 		"%s",
 		c.NukiId(),
 		c.Name(),
@@ -357,3 +453,165 @@ func (c ConfigCommand) String() string {
 		subPart,
 	)
 }
+
+func (c ConfigCommand) Id() Id {
+	return IdConfig
+}
+
+// configJSON is the json representation of a ConfigCommand. The device specific fields are only set for the
+// corresponding device type.
+type configJSON struct {
+	Id               Id       `json:"id"`
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`
+	NukiId           uint32   `json:"nukiId"`
+	DeviceName       string   `json:"deviceName"`
+	Latitude         *float32 `json:"latitude"`
+	Longitude        *float32 `json:"longitude"`
+	AutoUnlatch      *bool    `json:"autoUnlatch,omitempty"`
+	Capabilities     *string  `json:"capabilities,omitempty"`
+	PairingEnabled   bool     `json:"pairingEnabled"`
+	ButtonEnabled    bool     `json:"buttonEnabled"`
+	LEDEnabled       bool     `json:"ledEnabled"`
+	LEDBrightness    *uint8   `json:"ledBrightness,omitempty"`
+	CurrentTime      string   `json:"currentTime"`
+	TimezoneOffset   int16    `json:"timezoneOffset"`
+	DSTMode          string   `json:"dstMode"`
+	HasFob           bool     `json:"hasFob"`
+	FobAction1       uint8    `json:"fobAction1"`
+	FobAction2       uint8    `json:"fobAction2"`
+	FobAction3       uint8    `json:"fobAction3"`
+	SingleLock       *bool    `json:"singleLock,omitempty"`
+	OperationMode    *string  `json:"operationMode,omitempty"`
+	AdvertisingMode  string   `json:"advertisingMode"`
+	HasKeypad        bool     `json:"hasKeypad"`
+	FirmwareVersion  string   `json:"firmwareVersion"`
+	HardwareRevision string   `json:"hardwareRevision"`
+	HomeKitStatus    *string  `json:"homeKitStatus,omitempty"`
+	TimeZoneId       uint16   `json:"timeZoneId"`
+	// Payload is only used for unmarshalling the raw representation (see RawMessage).
+	Payload *string `json:"payload,omitempty"`
+}
+
+// MarshalJSON returns the config as json object with named enums (for example "advertisingMode":"normal"). Configs
+// of an unknown device type will be returned with their raw payload (see RawMessage).
+func (c ConfigCommand) MarshalJSON() ([]byte, error) {
+	if c.Validate() != nil || c.Type() == ConfigTypeUnknown {
+		return marshalRawJSON(Command(c))
+	}
+
+	payload := Command(c).Payload()
+	offset := 0
+	result := configJSON{
+		Id:               c.Id(),
+		Name:             c.Id().Name(),
+		NukiId:           c.NukiId(),
+		DeviceName:       formatName(payload[4:36]),
+		Latitude:         formatFloat(c.Latitude()),
+		Longitude:        formatFloat(c.Longitude()),
+		PairingEnabled:   c.PairingEnabled(),
+		ButtonEnabled:    c.ButtonEnabled(),
+		LEDEnabled:       c.LEDEnabled(),
+		DSTMode:          c.DSTMode().String(),
+		HasFob:           c.HasFob(),
+		FobAction1:       c.FobAction1(),
+		FobAction2:       c.FobAction2(),
+		FobAction3:       c.FobAction3(),
+		AdvertisingMode:  c.AdvertisingMode().String(),
+		HasKeypad:        c.HasKeypad(),
+		FirmwareVersion:  formatVersion(c.FirmwareVersion()),
+		HardwareRevision: formatVersion(c.HardwareRevision()),
+		TimeZoneId:       uint16(c.TimeZoneId()),
+	}
+
+	if smartLock := c.AsSmartLockConfig(); smartLock != nil {
+		//the smart lock has one additional byte (led brightness) before the current time
+		offset = 1
+
+		result.Type = jsonTypeSmartLock
+		result.AutoUnlatch = boolPtr(smartLock.AutoUnlatch())
+		result.LEDBrightness = uint8Ptr(smartLock.LEDBrightness())
+		result.SingleLock = boolPtr(smartLock.SingleLock())
+		result.HomeKitStatus = stringPtr(smartLock.HomeKitStatus().String())
+	} else if opener := c.AsOpenerConfig(); opener != nil {
+		result.Type = jsonTypeOpener
+		result.Capabilities = stringPtr(opener.Capabilities().String())
+		result.OperationMode = stringPtr(opener.OperationMode().String())
+	}
+	result.CurrentTime = formatDateTime(payload, 48+offset)
+	result.TimezoneOffset = int16(binary.LittleEndian.Uint16(payload[55+offset : 57+offset]))
+
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON converts the json representation (see MarshalJSON) back into the config command.
+func (c *ConfigCommand) UnmarshalJSON(data []byte) error {
+	var j configJSON
+	if err := unmarshalCommandJSON(data, IdConfig, &j); err != nil {
+		return err
+	}
+
+	var cmd Command
+	var err error
+	if j.Payload != nil {
+		cmd, err = unmarshalRawJSON(IdConfig, *j.Payload)
+	} else {
+		cmd, err = j.command()
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := cmd.ParseConfigCommand()
+	if err != nil {
+		return err
+	}
+	*c = config
+	return nil
+}
+
+func (j configJSON) command() (Command, error) {
+	if j.Type != jsonTypeSmartLock && j.Type != jsonTypeOpener {
+		return nil, fmt.Errorf("%w: unknown config type %q", InvalidJSONError, j.Type)
+	}
+	smartLock := j.Type == jsonTypeSmartLock
+
+	w := payloadWriter{}
+	w.uint32(j.NukiId)
+	w.name(j.DeviceName)
+	w.float32(j.Latitude)
+	w.float32(j.Longitude)
+	if smartLock {
+		w.bool(valueOf(j.AutoUnlatch))
+	} else {
+		w.enum(openerCapabilitiesNames, valueOf(j.Capabilities))
+	}
+	w.bool(j.PairingEnabled)
+	w.bool(j.ButtonEnabled)
+	w.bool(j.LEDEnabled)
+	if smartLock {
+		w.uint8(valueOf(j.LEDBrightness))
+	}
+	w.dateTime(j.CurrentTime)
+	w.uint16(uint16(j.TimezoneOffset))
+	w.enum(daylightSavingTimeModeNames, j.DSTMode)
+	w.bool(j.HasFob)
+	w.uint8(j.FobAction1)
+	w.uint8(j.FobAction2)
+	w.uint8(j.FobAction3)
+	if smartLock {
+		w.bool(valueOf(j.SingleLock))
+	} else {
+		w.enum(openerOperationModeNames, valueOf(j.OperationMode))
+	}
+	w.enum(advertisingModeNames, j.AdvertisingMode)
+	w.bool(j.HasKeypad)
+	w.version(j.FirmwareVersion, 3)
+	w.version(j.HardwareRevision, 2)
+	if smartLock {
+		w.enum(homeKitStatusNames, valueOf(j.HomeKitStatus))
+	}
+	w.uint16(j.TimeZoneId)
+
+	return w.command(IdConfig)
+}
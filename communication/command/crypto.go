@@ -19,7 +19,20 @@ import (
   +---------------------------------------------+-------------------------------------------------+
 */
 
+// NewSharedKey will precompute the shared key of the own private key and the public key of the nuki device.
+// The shared key should be computed once per session and be used for EncryptCommandWithSharedKey and
+// DecryptCommandWithSharedKey: the computation is much more expensive than the encryption itself.
+func NewSharedKey(privateKey []byte, nukiPubKey []byte) nacl.Key {
+	return box.Precompute(nacl.Key(nukiPubKey), nacl.Key(privateKey))
+}
+
+// EncryptCommand will encrypt the given command. See EncryptCommandWithSharedKey for the faster variant.
 func EncryptCommand(authId uint32, privateKey []byte, nukiPubKey []byte, plainCmd Command) Command {
+	return EncryptCommandWithSharedKey(authId, NewSharedKey(privateKey, nukiPubKey), plainCmd)
+}
+
+// EncryptCommandWithSharedKey will encrypt the given command with the given precomputed key (see NewSharedKey).
+func EncryptCommandWithSharedKey(authId uint32, sharedKey nacl.Key, plainCmd Command) Command {
 	pdata := make([]byte, 0, len(plainCmd)+4)
 
 	idAsByte := make([]byte, 4)
@@ -34,7 +47,6 @@ func EncryptCommand(authId uint32, privateKey []byte, nukiPubKey []byte, plainCm
 
 	pdata = append(pdata, crcPart...)
 
-	sharedKey := box.Precompute(nacl.Key(nukiPubKey), nacl.Key(privateKey))
 	nonce := newNonce192()
 
 	encrypted := box.SealAfterPrecomputation(nonce[:], pdata, nacl.Nonce(nonce), sharedKey)
@@ -52,16 +64,26 @@ func EncryptCommand(authId uint32, privateKey []byte, nukiPubKey []byte, plainCm
 	return message
 }
 
+// DecryptCommand will decrypt the given command. See DecryptCommandWithSharedKey for the faster variant.
 func DecryptCommand(encryptedCmd Command, privateKey []byte, nukiPubKey []byte) (authId uint32, decrypted Command) {
+	return DecryptCommandWithSharedKey(encryptedCmd, NewSharedKey(privateKey, nukiPubKey))
+}
+
+// DecryptCommandWithSharedKey will decrypt the given command with the given precomputed key (see NewSharedKey).
+func DecryptCommandWithSharedKey(encryptedCmd Command, sharedKey nacl.Key) (authId uint32, decrypted Command) {
+	if !IsCommandComplete(encryptedCmd) {
+		return 0, nil
+	}
+
 	nonce := encryptedCmd[:24]
 	//authId := encryptedCmd[24:28]
 	length := binary.LittleEndian.Uint16(encryptedCmd[28:30])
 
 	encrypted := encryptedCmd[30 : 30+length]
 
-	sharedKey := box.Precompute(nacl.Key(nukiPubKey), nacl.Key(privateKey))
 	decrypted, ok := box.OpenAfterPrecomputation(nil, encrypted, nacl.Nonce(nonce), sharedKey)
-	if !ok {
+	if !ok || len(decrypted) < 8 {
+		//authorization id, command id and crc are mandatory
 		return 0, nil
 	}
 
@@ -74,12 +96,17 @@ func DecryptCommand(encryptedCmd Command, privateKey []byte, nukiPubKey []byte)
 }
 
 func IsCommandComplete(encryptedCmd Command) bool {
-	length := len(encryptedCmd)
-	if length < 30 {
-		return false
+	expectedLength, ok := EncryptedCommandLength(encryptedCmd)
+	return ok && len(encryptedCmd) == expectedLength
+}
+
+// EncryptedCommandLength returns the length of the whole encrypted command which is announced by its (unencrypted)
+// header. Returns false if the header is not complete yet.
+func EncryptedCommandLength(encryptedCmd Command) (int, bool) {
+	if len(encryptedCmd) < 30 {
+		return 0, false
 	}
-	expectedLength := 30 + binary.LittleEndian.Uint16(encryptedCmd[28:30])
-	return length == int(expectedLength)
+	return 30 + int(binary.LittleEndian.Uint16(encryptedCmd[28:30])), true
 }
 
 // only for monkey patching purposes
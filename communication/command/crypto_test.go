@@ -36,3 +36,60 @@ func TestDecryptCommand(t *testing.T) {
 	assert.Equal(t, "020100E0070307080F1E3C0000200A", strings.ToUpper(hex.EncodeToString(result.Payload())))
 	assert.True(t, result.CheckCRC())
 }
+
+func TestDecryptCommandWithSharedKey(t *testing.T) {
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	encryptedCmd, _ := hex.DecodeString("90B0757CFED0243017EAF5E089F8583B9839D61B050924D2020000002700B13938B67121B6D528E7DE206B0D7C5A94587A471B33EBFB012CED8F1261135566ED756E3910B5")
+
+	authId, result := DecryptCommandWithSharedKey(encryptedCmd, NewSharedKey(privKey, nukiPubKey))
+
+	assert.Equal(t, uint32(2), authId)
+	assert.Equal(t, "020100E0070307080F1E3C0000200A", strings.ToUpper(hex.EncodeToString(result.Payload())))
+}
+
+func benchmarkKeys() ([]byte, []byte, Command) {
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	encryptedCmd, _ := hex.DecodeString("90B0757CFED0243017EAF5E089F8583B9839D61B050924D2020000002700B13938B67121B6D528E7DE206B0D7C5A94587A471B33EBFB012CED8F1261135566ED756E3910B5")
+
+	return privKey, nukiPubKey, encryptedCmd
+}
+
+func BenchmarkDecryptCommand(b *testing.B) {
+	privKey, nukiPubKey, encryptedCmd := benchmarkKeys()
+
+	for i := 0; i < b.N; i++ {
+		DecryptCommand(encryptedCmd, privKey, nukiPubKey)
+	}
+}
+
+func BenchmarkDecryptCommandWithSharedKey(b *testing.B) {
+	privKey, nukiPubKey, encryptedCmd := benchmarkKeys()
+	sharedKey := NewSharedKey(privKey, nukiPubKey)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecryptCommandWithSharedKey(encryptedCmd, sharedKey)
+	}
+}
+
+func BenchmarkEncryptCommand(b *testing.B) {
+	privKey, nukiPubKey, _ := benchmarkKeys()
+	cmd := NewRequest(IdKeyturnerStates)
+
+	for i := 0; i < b.N; i++ {
+		EncryptCommand(2, privKey, nukiPubKey, cmd)
+	}
+}
+
+func BenchmarkEncryptCommandWithSharedKey(b *testing.B) {
+	privKey, nukiPubKey, _ := benchmarkKeys()
+	sharedKey := NewSharedKey(privKey, nukiPubKey)
+	cmd := NewRequest(IdKeyturnerStates)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncryptCommandWithSharedKey(2, sharedKey, cmd)
+	}
+}
@@ -0,0 +1,329 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InvalidEnumError will be returned if a name can not be parsed into an enum value.
+var InvalidEnumError = fmt.Errorf("invalid enum name")
+
+// enumNames contains the names of the values of an enum.
+type enumNames map[uint8]string
+
+// with returns a copy of the names which is extended by the given names.
+func (n enumNames) with(additional enumNames) enumNames {
+	result := enumNames{}
+	for value, name := range n {
+		result[value] = name
+	}
+	for value, name := range additional {
+		result[value] = name
+	}
+	return result
+}
+
+// format returns the name of the given value. Values without a name will be formatted as hex number.
+func (n enumNames) format(value uint8) string {
+	if name, ok := n[value]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", value)
+}
+
+// parse returns the value of the given name. Hex numbers (see format) will be accepted too.
+func (n enumNames) parse(name string) (uint8, error) {
+	for value, valueName := range n {
+		if valueName == name {
+			return value, nil
+		}
+	}
+	if strings.HasPrefix(name, "0x") {
+		if value, err := strconv.ParseUint(name[2:], 16, 8); err == nil {
+			return uint8(value), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", InvalidEnumError, name)
+}
+
+// deviceEnumNames contains the names of an enum whose values have a different meaning for each device type.
+type deviceEnumNames struct {
+	smartLock enumNames
+	opener    enumNames
+}
+
+// names returns the names for the given type. If the type is unknown, the names of both devices are combined.
+func (d deviceEnumNames) names(statesType StatesType) enumNames {
+	switch statesType {
+	case StatesTypeSmartLock:
+		return d.smartLock
+	case StatesTypeOpener:
+		return d.opener
+	}
+
+	result := d.smartLock.with(nil)
+	for value, name := range d.opener {
+		if smartLockName, ok := result[value]; ok && smartLockName != name {
+			result[value] = smartLockName + "/" + name
+		} else {
+			result[value] = name
+		}
+	}
+	return result
+}
+
+var nukiStateNames = func() deviceEnumNames {
+	common := enumNames{
+		uint8(NukiStateUninitialized):   "uninitialized",
+		uint8(NukiStatePairingMode):     "pairingMode",
+		uint8(NukiStateDoorMode):        "doorMode",
+		uint8(NukiStateMaintenanceMode): "maintenanceMode",
+	}
+	return deviceEnumNames{
+		smartLock: common,
+		opener: common.with(enumNames{
+			uint8(NukiStateOpenerContinuousMode): "continuousMode",
+		}),
+	}
+}()
+
+var lockStateNames = deviceEnumNames{
+	smartLock: enumNames{
+		uint8(LockStateUncalibrated):                     "uncalibrated",
+		uint8(LockStateLocked):                           "locked",
+		uint8(LockStateSmartLockUnlocking):               "unlocking",
+		uint8(LockStateSmartLockUnlocked):                "unlocked",
+		uint8(LockStateSmartLockLocking):                 "locking",
+		uint8(LockStateSmartLockUnlatched):               "unlatched",
+		uint8(LockStateSmartLockUnlockedLockAndGoActive): "unlockedLockNGo",
+		uint8(LockStateSmartLockUnlatching):              "unlatching",
+		uint8(LockStateSmartLockCalibration):             "calibration",
+		uint8(LockStateSmartLockBootRun):                 "bootRun",
+		uint8(LockStateSmartLockMotorBlocked):            "motorBlocked",
+		uint8(LockStateUndefined):                        "undefined",
+	},
+	opener: enumNames{
+		uint8(LockStateUncalibrated):    "untrained",
+		uint8(LockStateLocked):          "online",
+		uint8(LockStateOpenerRTOActive): "rtoActive",
+		uint8(LockStateOpenerOpen):      "open",
+		uint8(LockStateOpenerOpening):   "opening",
+		uint8(LockStateOpenerBootRun):   "bootRun",
+		uint8(LockStateUndefined):       "undefined",
+	},
+}
+
+var triggerNames = func() deviceEnumNames {
+	common := enumNames{
+		uint8(TriggerSystem):    "system",
+		uint8(TriggerManual):    "manual",
+		uint8(TriggerButton):    "button",
+		uint8(TriggerAutomatic): "automatic",
+	}
+	return deviceEnumNames{
+		smartLock: common.with(enumNames{
+			uint8(TriggerSmartLockAutoLock): "autoLock",
+		}),
+		opener: common,
+	}
+}()
+
+var lockActionNames = deviceEnumNames{
+	smartLock: enumNames{
+		uint8(LockActionUnlock):               "unlock",
+		uint8(LockActionLock):                 "lock",
+		uint8(LockActionUnlatch):              "unlatch",
+		uint8(LockActionLockAndGo):            "lockNGo",
+		uint8(LockActionLockAndGoWithUnlatch): "lockNGoWithUnlatch",
+		uint8(LockActionFullLock):             "fullLock",
+		uint8(LockActionFobAction1):           "fobAction1",
+		uint8(LockActionFobAction2):           "fobAction2",
+		uint8(LockActionFobAction3):           "fobAction3",
+	},
+	opener: enumNames{
+		uint8(OpenActionActivateRTO):             "activateRto",
+		uint8(OpenActionDeactivateRTO):           "deactivateRto",
+		uint8(OpenActionElectricStrikeActuation): "electricStrikeActuation",
+		uint8(OpenActionActivateCm):              "activateContinuousMode",
+		uint8(OpenActionDeactivateCm):            "deactivateContinuousMode",
+		uint8(OpenActionFobAction1):              "fobAction1",
+		uint8(OpenActionFobAction2):              "fobAction2",
+		uint8(OpenActionFobAction3):              "fobAction3",
+	},
+}
+
+var doorSensorStateNames = enumNames{
+	uint8(DoorSensorStateUnavailable):      "unavailable",
+	uint8(DoorSensorStateDeactivated):      "deactivated",
+	uint8(DoorSensorStateDoorClosed):       "doorClosed",
+	uint8(DoorSensorStateDoorOpened):       "doorOpened",
+	uint8(DoorSensorStateDoorStateUnknown): "doorStateUnknown",
+	uint8(DoorSensorStateCalibrating):      "calibrating",
+}
+
+var completionStatusNames = enumNames{
+	uint8(CompletionStatusComplete): "complete",
+	uint8(CompletionStatusAccepted): "accepted",
+}
+
+// lockActionCompletionStatusNames are the names of the completion status of the last lock action (see states and
+// log entries). Unlike the status command, the value 0x01 means that the motor was blocked.
+var lockActionCompletionStatusNames = enumNames{
+	0x00: "success",
+	0x01: "motorBlocked",
+	0x02: "canceled",
+	0x03: "tooRecent",
+	0x04: "busy",
+	0x05: "lowMotorVoltage",
+	0x06: "clutchFailure",
+	0x07: "motorPowerFailure",
+	0x08: "incompleteFailure",
+	0xFE: "otherError",
+	0xFF: "unknown",
+}
+
+var advertisingModeNames = enumNames{
+	uint8(AdvertisingModeAutomatic): "automatic",
+	uint8(AdvertisingModeNormal):    "normal",
+	uint8(AdvertisingModeSlow):      "slow",
+	uint8(AdvertisingModeSlowest):   "slowest",
+}
+
+var openerOperationModeNames = enumNames{
+	uint8(OpenerOperationModeGenericDoorOpener):          "genericDoorOpener",
+	uint8(OpenerOperationModeAnalogueIntercom):           "analogueIntercom",
+	uint8(OpenerOperationModeDigitalIntercom):            "digitalIntercom",
+	uint8(OpenerOperationModeDigitalIntercomSiedle):      "digitalIntercomSiedle",
+	uint8(OpenerOperationModeDigitalIntercomTCS):         "digitalIntercomTCS",
+	uint8(OpenerOperationModeDigitalIntercomBticino):     "digitalIntercomBticino",
+	uint8(OpenerOperationModeAnalogIntercomSiedleHTS):    "analogIntercomSiedleHTS",
+	uint8(OpenerOperationModeDigitalIntercomSTR):         "digitalIntercomSTR",
+	uint8(OpenerOperationModeDigitalIntercomRitto):       "digitalIntercomRitto",
+	uint8(OpenerOperationModeDigitalIntercomFermax):      "digitalIntercomFermax",
+	uint8(OpenerOperationModeDigitalIntercomComelit):     "digitalIntercomComelit",
+	uint8(OpenerOperationModeDigitalIntercomUrmetBiBus):  "digitalIntercomUrmetBiBus",
+	uint8(OpenerOperationModeDigitalIntercomUrmet2Voice): "digitalIntercomUrmet2Voice",
+	uint8(OpenerOperationModeDigitalIntercomGolmar):      "digitalIntercomGolmar",
+	uint8(OpenerOperationModeDigitalIntercomSKS):         "digitalIntercomSKS",
+	uint8(OpenerOperationModeDigitalIntercomSpare):       "digitalIntercomSpare",
+}
+
+var daylightSavingTimeModeNames = enumNames{
+	uint8(DaylightSavingTimeModeDisabled): "disabled",
+	uint8(DaylightSavingTimeModeEuropean): "european",
+	uint8(DaylightSavingTimeModeUnknown):  "unknown",
+}
+
+var homeKitStatusNames = enumNames{
+	uint8(HomeKitStatusNotAvailable):     "notAvailable",
+	uint8(HomeKitStatusDisabled):         "disabled",
+	uint8(HomeKitStatusEnabled):          "enabled",
+	uint8(HomeKitStatusEnabledAndPaired): "enabledAndPaired",
+}
+
+var openerCapabilitiesNames = enumNames{
+	uint8(OpenerCapabilitiesOnlyDoorOpening): "onlyDoorOpening",
+	uint8(OpenerCapabilitiesBoth):            "both",
+	uint8(OpenerCapabilitiesOnlyRto):         "onlyRto",
+}
+
+var loggingTypeNames = enumNames{
+	uint8(LoggingTypeLoggingEnabledDisabled):           "loggingEnabledDisabled",
+	uint8(LoggingTypeLockAction):                       "lockAction",
+	uint8(LoggingTypeCalibration):                      "calibration",
+	uint8(LoggingTypeInitializationRun):                "initializationRun",
+	uint8(LoggingTypeKeypadAction):                     "keypadAction",
+	uint8(LoggingTypeDoorSensor):                       "doorSensor",
+	uint8(LoggingTypeDoorSensorLoggingEnabledDisabled): "doorSensorLoggingEnabledDisabled",
+}
+
+// Name returns the name of the nuki state for the given device type.
+func (s NukiState) Name(statesType StatesType) string {
+	return nukiStateNames.names(statesType).format(uint8(s))
+}
+
+// String returns the name of the nuki state. If the meaning is different for smart locks and openers, both names
+// are returned (see Name).
+func (s NukiState) String() string {
+	return s.Name(StatesTypeUnknown)
+}
+
+// Name returns the name of the lock state for the given device type (for example "unlocked" or "rtoActive").
+func (l LockState) Name(statesType StatesType) string {
+	return lockStateNames.names(statesType).format(uint8(l))
+}
+
+// String returns the name of the lock state. If the meaning is different for smart locks and openers, both names
+// are returned (for example "unlocked/rtoActive"). See Name.
+func (l LockState) String() string {
+	return l.Name(StatesTypeUnknown)
+}
+
+// Name returns the name of the trigger for the given device type.
+func (t Trigger) Name(statesType StatesType) string {
+	return triggerNames.names(statesType).format(uint8(t))
+}
+
+// String returns the name of the trigger. See Name.
+func (t Trigger) String() string {
+	return t.Name(StatesTypeUnknown)
+}
+
+// Name returns the name of the lock action for the given device type (for the opener see OpenAction).
+func (l LockAction) Name(statesType StatesType) string {
+	return lockActionNames.names(statesType).format(uint8(l))
+}
+
+// String returns the name of the lock action. See Name.
+func (l LockAction) String() string {
+	return l.Name(StatesTypeSmartLock)
+}
+
+// String returns the name of the open action.
+func (o OpenAction) String() string {
+	return LockAction(o).Name(StatesTypeOpener)
+}
+
+func (d DoorSensorState) String() string {
+	return doorSensorStateNames.format(uint8(d))
+}
+
+func (c CompletionStatus) String() string {
+	return completionStatusNames.format(uint8(c))
+}
+
+func (c LockActionCompletionStatus) String() string {
+	return lockActionCompletionStatusNames.format(uint8(c))
+}
+
+func (a AdvertisingMode) String() string {
+	return advertisingModeNames.format(uint8(a))
+}
+
+func (o OpenerOperationMode) String() string {
+	return openerOperationModeNames.format(uint8(o))
+}
+
+func (d DaylightSavingTimeMode) String() string {
+	return daylightSavingTimeModeNames.format(uint8(d))
+}
+
+func (h HomeKitStatus) String() string {
+	return homeKitStatusNames.format(uint8(h))
+}
+
+func (o OpenerCapabilities) String() string {
+	return openerCapabilitiesNames.format(uint8(o))
+}
+
+func (l LoggingType) String() string {
+	return loggingTypeNames.format(uint8(l))
+}
+
+// doorSensorEventNames are the names of the door sensor events of the log entries.
+var doorSensorEventNames = enumNames{
+	0x00: "opened",
+	0x01: "closed",
+	0x02: "jammed",
+}
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLockState_Name(t *testing.T) {
+	assert.Equal(t, "unlocked", LockStateSmartLockUnlocked.Name(StatesTypeSmartLock))
+	assert.Equal(t, "rtoActive", LockStateOpenerRTOActive.Name(StatesTypeOpener))
+	assert.Equal(t, "unlocked/rtoActive", LockState(0x03).String())
+	assert.Equal(t, "locked/online", LockStateLocked.String())
+	assert.Equal(t, "undefined", LockStateUndefined.String())
+	assert.Equal(t, "0x42", LockState(0x42).String())
+}
+
+func TestEnum_String(t *testing.T) {
+	assert.Equal(t, "continuousMode", NukiStateOpenerContinuousMode.Name(StatesTypeOpener))
+	assert.Equal(t, "0x03", NukiStateOpenerContinuousMode.Name(StatesTypeSmartLock))
+	assert.Equal(t, "autoLock", TriggerSmartLockAutoLock.String())
+	assert.Equal(t, "doorClosed", DoorSensorStateDoorClosed.String())
+	assert.Equal(t, "lockNGo", LockActionLockAndGo.String())
+	assert.Equal(t, "electricStrikeActuation", OpenActionElectricStrikeActuation.String())
+	assert.Equal(t, "accepted", CompletionStatusAccepted.String())
+	assert.Equal(t, "slowest", AdvertisingModeSlowest.String())
+	assert.Equal(t, "digitalIntercomSiedle", OpenerOperationModeDigitalIntercomSiedle.String())
+	assert.Equal(t, "keypadAction", LoggingTypeKeypadAction.String())
+}
+
+func TestEnumNames_Parse(t *testing.T) {
+	value, err := lockStateNames.names(StatesTypeOpener).parse("rtoActive")
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(LockStateOpenerRTOActive), value)
+
+	value, err = lockStateNames.names(StatesTypeOpener).parse("0x42")
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), value)
+
+	_, err = lockStateNames.names(StatesTypeOpener).parse("unlocked")
+	assert.ErrorIs(t, err, InvalidEnumError)
+}
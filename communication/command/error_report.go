@@ -0,0 +1,52 @@
+package command
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+type ErrorReportCommand Command
+
+// ParseErrorReportCommand returns the command as ErrorReportCommand. Returns an error if the command has an other id
+// or is too short.
+func (c Command) ParseErrorReportCommand() (ErrorReportCommand, error) {
+	if err := c.parse(IdErrorReport); err != nil {
+		return nil, err
+	}
+	return ErrorReportCommand(c), nil
+}
+
+// Validate checks if the command is a valid ErrorReportCommand.
+func (e ErrorReportCommand) Validate() error {
+	return Command(e).parse(IdErrorReport)
+}
+
+func (e ErrorReportCommand) Id() Id {
+	return IdErrorReport
+}
+
+func (e ErrorReportCommand) Code() uint8 {
+	return Command(e).byteAt(0)
+}
+
+// CommandId returns the id of the command which caused the error. Returns 0 if the device did not report it.
+func (e ErrorReportCommand) CommandId() Id {
+	if len(Command(e).Payload()) < 3 {
+		return 0
+	}
+	return Id(binary.LittleEndian.Uint16(Command(e).bytesAt(1, 3)))
+}
+
+func (e ErrorReportCommand) String() string {
+	return fmt.Sprintf("Error code: 0x%02x; Command: %s", e.Code(), e.CommandId().Name())
+}
+
+func (e ErrorReportCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id        Id     `json:"id"`
+		Name      string `json:"name"`
+		Code      uint8  `json:"code"`
+		CommandId Id     `json:"commandId"`
+	}{e.Id(), e.Id().Name(), e.Code(), e.CommandId()})
+}
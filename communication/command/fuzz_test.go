@@ -0,0 +1,276 @@
+package command
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// traceEncryptedStates is an encrypted keyturner states frame of the nuki api documentation.
+const traceEncryptedStates = "90B0757CFED0243017EAF5E089F8583B9839D61B050924D2020000002700B13938B67121B6D528E7DE206B0D7C5A94587A471B33EBFB012CED8F1261135566ED756E3910B5"
+
+// seedPayloads contains the payloads of frames like they are sent by real devices.
+var seedPayloads = map[Id][][]byte{
+	IdStates: {
+		decodeHex("020100E6070A13091E2A3C0010000001010002000000"),         //smart lock
+		decodeHex("020100E6070A13091E2A3C000000000101000000000000000000"), //opener
+		decodeHex("020100E0070307080F1E3C0000200A"),                       //truncated (older firmware)
+	},
+	IdStatus:          {{0x00}, {0x01}},
+	IdChallenge:       {decodeHex("6CD4163D159050C798553EAA57E278A579AFFCBC56F09FC57FE879E51C42DF17")},
+	IdPublicKey:       {decodeHex("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")},
+	IdAuthorizationID: {make([]byte, 84), make([]byte, 52)},
+	IdConfig:          {make([]byte, 74), make([]byte, 72), make([]byte, 48)},
+	IdLogEntryCount:   {{0x01, 0x2A, 0x00, 0x01, 0x01}, {0x01, 0x2A, 0x00}},
+}
+
+func decodeHex(s string) []byte {
+	raw, _ := hex.DecodeString(s)
+	return raw
+}
+
+func addPayloadSeeds(f *testing.F, id Id) {
+	for _, payload := range seedPayloads[id] {
+		f.Add(payload)
+	}
+}
+
+func FuzzCommand(f *testing.F) {
+	for id, payloads := range seedPayloads {
+		for _, payload := range payloads {
+			f.Add([]byte(NewCommand(id, payload)))
+		}
+	}
+	f.Add([]byte(NewCommand(IdErrorReport, []byte{0x45, 0x0D, 0x00})))
+	f.Add([]byte{})
+	f.Add([]byte{0x0C})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		cmd := Command(raw)
+
+		_ = cmd.Id()
+		_ = cmd.Payload()
+		_ = cmd.CrcSum()
+		_ = cmd.CheckCRC()
+		_ = cmd.String()
+		_ = cmd.Validate()
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	for id, payloads := range seedPayloads {
+		for _, payload := range payloads {
+			f.Add([]byte(NewCommand(id, payload)))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		message, err := Decode(raw)
+		if err != nil {
+			return
+		}
+
+		_ = message.String()
+		if _, err := message.MarshalJSON(); err != nil {
+			t.Errorf("unable to marshal %s: %s", message.Id().Name(), err)
+		}
+	})
+}
+
+func FuzzAsStatesCommand(f *testing.F) {
+	addPayloadSeeds(f, IdStates)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		states := NewCommand(IdStates, payload).AsStatesCommand()
+		if states == nil {
+			return
+		}
+
+		_ = states.String()
+		if smartLock := states.AsSmartLockStates(); smartLock != nil {
+			_ = smartLock.String()
+		}
+		if opener := states.AsOpenerStates(); opener != nil {
+			_ = opener.String()
+		}
+	})
+}
+
+func FuzzAsStatusCommand(f *testing.F) {
+	addPayloadSeeds(f, IdStatus)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if status := NewCommand(IdStatus, payload).AsStatusCommand(); status != nil {
+			_ = status.IsComplete()
+			_ = status.IsAccepted()
+		}
+	})
+}
+
+func FuzzAsChallengeCommand(f *testing.F) {
+	addPayloadSeeds(f, IdChallenge)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if challenge := NewCommand(IdChallenge, payload).AsChallengeCommand(); challenge != nil {
+			_ = challenge.Nonce()
+		}
+	})
+}
+
+func FuzzAsPublicKeyCommand(f *testing.F) {
+	addPayloadSeeds(f, IdPublicKey)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if publicKey := NewCommand(IdPublicKey, payload).AsPublicKeyCommand(); publicKey != nil {
+			_ = publicKey.PublicKey()
+		}
+	})
+}
+
+func FuzzAsAuthorizationIdCommand(f *testing.F) {
+	addPayloadSeeds(f, IdAuthorizationID)
+
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		authId := NewCommand(IdAuthorizationID, payload).AsAuthorizationIdCommand()
+		if authId == nil {
+			return
+		}
+
+		_ = authId.Authenticator()
+		_ = authId.AuthorizationId()
+		_ = authId.UUID()
+		_ = authId.Nonce()
+		_ = authId.VerifyAuthenticator(nukiPubKey, privKey, make([]byte, 32))
+	})
+}
+
+func FuzzAsConfigCommand(f *testing.F) {
+	addPayloadSeeds(f, IdConfig)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		config := NewCommand(IdConfig, payload).AsConfigCommand()
+		if config == nil {
+			return
+		}
+
+		_ = config.String()
+		_ = config.TimeZoneId().String()
+	})
+}
+
+func FuzzAsLogEntryCommand(f *testing.F) {
+	for _, loggingType := range []LoggingType{
+		LoggingTypeLoggingEnabledDisabled, LoggingTypeLockAction, LoggingTypeCalibration, LoggingTypeInitializationRun,
+		LoggingTypeKeypadAction, LoggingTypeDoorSensor, LoggingTypeDoorSensorLoggingEnabledDisabled, LoggingType(0xFF),
+	} {
+		payload := make([]byte, 53)
+		binary.LittleEndian.PutUint32(payload[0:4], 1)
+		binary.LittleEndian.PutUint16(payload[4:6], 2022)
+		payload[6], payload[7] = 10, 19
+		copy(payload[15:47], "Nuki Fob")
+		payload[47] = byte(loggingType)
+
+		f.Add(payload)
+		f.Add(payload[:48])
+	}
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		logEntry := NewCommand(IdLogEntry, payload).AsLogEntryCommand()
+		if logEntry == nil {
+			return
+		}
+
+		_ = logEntry.String()
+	})
+}
+
+func FuzzAsLogEntriesCountCommand(f *testing.F) {
+	addPayloadSeeds(f, IdLogEntryCount)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		if count := NewCommand(IdLogEntryCount, payload).AsLogEntriesCountCommand(); count != nil {
+			_ = count.String()
+		}
+	})
+}
+
+func FuzzIsCommandComplete(f *testing.F) {
+	f.Add([]byte(Command(decodeHex(traceEncryptedStates))))
+	f.Add([]byte(Command(decodeHex(traceEncryptedStates))[:30]))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		complete := IsCommandComplete(raw)
+		if complete && len(raw) < 30 {
+			t.Errorf("command of %d bytes can not be complete", len(raw))
+		}
+	})
+}
+
+func FuzzDecryptCommand(f *testing.F) {
+	privKey, nukiPubKey, _ := benchmarkKeys()
+	sharedKey := NewSharedKey(privKey, nukiPubKey)
+
+	encryptedStates := Command(decodeHex(traceEncryptedStates))
+	f.Add([]byte(encryptedStates), []byte(nil))
+	f.Add([]byte(encryptedStates[:29]), []byte(nil))
+	f.Add([]byte(encryptedStates[:40]), []byte(nil))
+	f.Add([]byte(nil), []byte{0x02, 0x00, 0x00, 0x00})
+	f.Add([]byte(nil), []byte{0x02, 0x00, 0x00, 0x00, 0x0C, 0x00, 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, encrypted []byte, plain []byte) {
+		//arbitrary bytes (most of them can not be authenticated)
+		DecryptCommandWithSharedKey(encrypted, sharedKey)
+
+		//authenticated messages with arbitrary content
+		DecryptCommandWithSharedKey(seal(sharedKey, plain), sharedKey)
+	})
+}
+
+// seal encrypts the given (already authorization id prefixed) plain data like a device does.
+func seal(sharedKey nacl.Key, plain []byte) Command {
+	nonce := make([]byte, 24)
+	encrypted := box.SealAfterPrecomputation(nil, plain, nacl.Nonce(nonce), sharedKey)
+
+	message := make([]byte, 30, 30+len(encrypted))
+	copy(message, nonce)
+	binary.LittleEndian.PutUint16(message[28:30], uint16(len(encrypted)))
+	return append(message, encrypted...)
+}
+
+func TestCommand_CheckCRC_Short(t *testing.T) {
+	assert.NotPanics(t, func() {
+		assert.False(t, Command{0x0C}.CheckCRC())
+		assert.False(t, Command{0x0C, 0x00, 0x00}.CheckCRC())
+	})
+}
+
+func TestDecryptCommand_Truncated(t *testing.T) {
+	privKey, nukiPubKey, encryptedCmd := benchmarkKeys()
+	sharedKey := NewSharedKey(privKey, nukiPubKey)
+
+	for _, length := range []int{0, 1, 24, 29, 30, 40, len(encryptedCmd) - 1} {
+		assert.NotPanics(t, func() {
+			_, decrypted := DecryptCommandWithSharedKey(encryptedCmd[:length], sharedKey)
+			assert.Nil(t, decrypted, "length %d", length)
+		})
+	}
+}
+
+func TestDecryptCommand_ShortPlainData(t *testing.T) {
+	privKey, nukiPubKey, _ := benchmarkKeys()
+	sharedKey := NewSharedKey(privKey, nukiPubKey)
+
+	for _, plain := range [][]byte{nil, {0x02}, {0x02, 0x00, 0x00, 0x00}, {0x02, 0x00, 0x00, 0x00, 0x0C, 0x00, 0x00}} {
+		assert.NotPanics(t, func() {
+			_, decrypted := DecryptCommandWithSharedKey(seal(sharedKey, plain), sharedKey)
+			assert.Nil(t, decrypted)
+		})
+	}
+}
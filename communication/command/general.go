@@ -99,6 +99,9 @@ func (c Command) CheckCRC() bool {
 	if len(c) == 0 {
 		return true
 	}
+	if len(c) < 4 {
+		return false
+	}
 
 	return crc16.ChecksumCCITTFalse(c[:len(c)-2]) == c.CrcSum()
 }
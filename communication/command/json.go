@@ -0,0 +1,209 @@
+package command
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// InvalidJSONError will be returned if a json representation can not be converted back into a command.
+var InvalidJSONError = fmt.Errorf("invalid json representation of command")
+
+const (
+	jsonTypeSmartLock = "smartLock"
+	jsonTypeOpener    = "opener"
+)
+
+// formatDateTime formats the date time (year, month, day, hour, minute, second) which starts at the given offset of
+// the payload. The values are formatted as they are: so invalid dates (for example month 13) survive a round trip.
+func formatDateTime(payload []byte, offset int) string {
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d",
+		binary.LittleEndian.Uint16(payload[offset:offset+2]),
+		payload[offset+2], payload[offset+3], payload[offset+4], payload[offset+5], payload[offset+6],
+	)
+}
+
+// formatVersion formats the given version parts (for example "1.2.3").
+func formatVersion(raw []byte) string {
+	parts := make([]string, len(raw))
+	for i, part := range raw {
+		parts[i] = strconv.Itoa(int(part))
+	}
+	return strings.Join(parts, ".")
+}
+
+// formatName returns the name of a (zero padded) name field.
+func formatName(raw []byte) string {
+	return strings.TrimRight(string(raw), "\x00")
+}
+
+// formatFloat returns nil for values which can not be represented in json.
+func formatFloat(value float32) *float32 {
+	if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+		return nil
+	}
+	return &value
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func uint8Ptr(value uint8) *uint8 {
+	return &value
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+// valueOf returns the value of the given pointer or the zero value if it is nil.
+func valueOf[T any](value *T) T {
+	if value == nil {
+		var zero T
+		return zero
+	}
+	return *value
+}
+
+// payloadWriter builds a payload from the fields of a json representation. The first error will be kept and all
+// following writes will be ignored.
+type payloadWriter struct {
+	payload []byte
+	err     error
+}
+
+func (w *payloadWriter) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *payloadWriter) uint8(value uint8) {
+	w.payload = append(w.payload, value)
+}
+
+func (w *payloadWriter) uint16(value uint16) {
+	raw := make([]byte, 2)
+	binary.LittleEndian.PutUint16(raw, value)
+	w.payload = append(w.payload, raw...)
+}
+
+func (w *payloadWriter) uint32(value uint32) {
+	raw := make([]byte, 4)
+	binary.LittleEndian.PutUint32(raw, value)
+	w.payload = append(w.payload, raw...)
+}
+
+func (w *payloadWriter) float32(value *float32) {
+	if value == nil {
+		w.uint32(0)
+		return
+	}
+	w.uint32(math.Float32bits(*value))
+}
+
+func (w *payloadWriter) bool(value bool) {
+	if value {
+		w.uint8(0x01)
+	} else {
+		w.uint8(0x00)
+	}
+}
+
+// optionalBool writes the value if it is given. Returns false otherwise.
+func (w *payloadWriter) optionalBool(value *bool) bool {
+	if value == nil {
+		return false
+	}
+	w.bool(*value)
+	return true
+}
+
+func (w *payloadWriter) enum(names enumNames, name string) {
+	value, err := names.parse(name)
+	if err != nil {
+		w.fail(err)
+	}
+	w.uint8(value)
+}
+
+func (w *payloadWriter) name(name string) {
+	raw := make([]byte, 32)
+	copy(raw, name)
+	w.payload = append(w.payload, raw...)
+}
+
+func (w *payloadWriter) dateTime(value string) {
+	var year uint16
+	var month, day, hour, minute, second uint8
+	_, err := fmt.Sscanf(value, "%d-%d-%dT%d:%d:%d", &year, &month, &day, &hour, &minute, &second)
+	if err != nil {
+		w.fail(fmt.Errorf("%w: invalid date time %q", InvalidJSONError, value))
+	}
+
+	w.uint16(year)
+	w.payload = append(w.payload, month, day, hour, minute, second)
+}
+
+// version writes a version which consists of the given count of parts (for example "1.2.3").
+func (w *payloadWriter) version(value string, parts int) {
+	fields := strings.Split(value, ".")
+	if len(fields) != parts {
+		w.fail(fmt.Errorf("%w: invalid version %q", InvalidJSONError, value))
+		fields = make([]string, parts)
+	}
+
+	for _, field := range fields {
+		part, err := strconv.ParseUint(field, 10, 8)
+		if err != nil {
+			w.fail(fmt.Errorf("%w: invalid version %q", InvalidJSONError, value))
+		}
+		w.uint8(uint8(part))
+	}
+}
+
+func (w *payloadWriter) hex(value string) {
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		w.fail(fmt.Errorf("%w: %s", InvalidJSONError, err.Error()))
+	}
+	w.payload = append(w.payload, raw...)
+}
+
+// command returns the command with the written payload.
+func (w *payloadWriter) command(id Id) (Command, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return NewCommand(id, w.payload), nil
+}
+
+// unmarshalRawJSON converts the json representation of a command whose payload is not decoded (see marshalRawJSON).
+func unmarshalRawJSON(id Id, payload string) (Command, error) {
+	w := payloadWriter{}
+	w.hex(payload)
+	return w.command(id)
+}
+
+// unmarshalCommandJSON will unmarshal the given data into the given json representation and check the command id.
+func unmarshalCommandJSON(data []byte, expected Id, target interface{}) error {
+	if err := json.Unmarshal(data, target); err != nil {
+		return err
+	}
+
+	var header struct {
+		Id *Id `json:"id"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Id != nil && *header.Id != expected {
+		return fmt.Errorf("%w: expect 0x%04X got 0x%04X", UnexpectedCommandError, uint16(expected), uint16(*header.Id))
+	}
+	return nil
+}
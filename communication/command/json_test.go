@@ -0,0 +1,179 @@
+package command
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func assertJSONRoundTrip[T Message](t *testing.T, message T, expected string) {
+	raw, err := json.Marshal(message)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(raw))
+
+	var result T
+	assert.NoError(t, json.Unmarshal(raw, &result))
+	assert.Equal(t, message, result)
+}
+
+func TestStatesCommand_JSON_SmartLock(t *testing.T) {
+	states := NewCommand(IdStates, decodeHex("020301E6070A13091E2A3C004A0500020600020103")).AsStatesCommand()
+
+	assertJSONRoundTrip(t, states, `{
+		"id": 12, "name": "States", "type": "smartLock",
+		"nukiState": "doorMode", "lockState": "unlocked", "trigger": "manual",
+		"currentTime": "2022-10-19T09:30:42", "timezoneOffset": 60,
+		"batteryCritical": false, "batteryCharging": true, "batteryPercentage": 36,
+		"configUpdateCount": 5, "lockNGoTimer": 0,
+		"lastLockAction": "lock", "lastLockActionTrigger": "autoLock", "lastLockActionCompletionStatus": "success",
+		"doorSensorState": "doorClosed", "nightModeActive": true,
+		"accessoryBatterySupported": true, "accessoryBatteryCritical": true
+	}`)
+}
+
+func TestStatesCommand_JSON_Opener(t *testing.T) {
+	states := NewCommand(IdStates, decodeHex("020300E6070A13091E2A3C00010000030100FF0000000042")).AsStatesCommand()
+
+	assertJSONRoundTrip(t, states, `{
+		"id": 12, "name": "States", "type": "opener",
+		"nukiState": "doorMode", "lockState": "rtoActive", "trigger": "system",
+		"currentTime": "2022-10-19T09:30:42", "timezoneOffset": 60,
+		"batteryCritical": true, "configUpdateCount": 0, "ringToOpenTimer": 0,
+		"lastLockAction": "electricStrikeActuation", "lastLockActionTrigger": "manual", "lastLockActionCompletionStatus": "success",
+		"doorSensorState": "0xff", "extra": "0000000042"
+	}`)
+}
+
+func TestStatesCommand_UnmarshalJSON(t *testing.T) {
+	var states StatesCommand
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"opener","nukiState":"continuousMode","lockState":"open","trigger":"button",
+		"currentTime":"2022-10-19T09:30:42","lastLockAction":"0x03","lastLockActionTrigger":"system",
+		"lastLockActionCompletionStatus":"motorBlocked","doorSensorState":"unavailable"}`), &states))
+	assert.Equal(t, StatesTypeOpener, states.Type())
+	assert.Equal(t, NukiStateOpenerContinuousMode, states.NukiState())
+	assert.Equal(t, LockStateOpenerOpen, states.LockState())
+	assert.Equal(t, LockAction(0x03), states.LastLockAction())
+
+	//the raw representation is accepted too
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":12,"payload":"020301E6070A13091E2A3C004A0500020600020103"}`), &states))
+	assert.Equal(t, StatesTypeSmartLock, states.Type())
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"type":"smartLock","nukiState":"continuousMode"}`), &states), InvalidEnumError)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"type":"door"}`), &states), InvalidJSONError)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"id":14,"type":"smartLock"}`), &states), UnexpectedCommandError)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"payload":"0203"}`), &states), BadLengthError)
+}
+
+func testConfigPayload(length int) []byte {
+	payload := make([]byte, length)
+	binary.LittleEndian.PutUint32(payload[0:4], 0x2A0B0C0D)
+	copy(payload[4:36], "Front door")
+	binary.LittleEndian.PutUint32(payload[36:40], math.Float32bits(52.5))
+	binary.LittleEndian.PutUint32(payload[40:44], math.Float32bits(13.25))
+	return payload
+}
+
+func TestConfigCommand_JSON_SmartLock(t *testing.T) {
+	payload := testConfigPayload(74)
+	copy(payload[44:], []byte{0x01, 0x01, 0x01, 0x01, 0x03})
+	copy(payload[49:], []byte{0xE6, 0x07, 0x0A, 0x13, 0x09, 0x1E, 0x2A, 0x78, 0x00, 0x01})
+	copy(payload[59:], []byte{0x01, 0x01, 0x02, 0x03, 0x00, 0x01, 0x01, 0x03, 0x0A, 0x02, 0x05, 0x00, 0x02, 0x25, 0x00})
+	config := NewCommand(IdConfig, payload).AsConfigCommand()
+
+	assertJSONRoundTrip(t, config, `{
+		"id": 21, "name": "Config", "type": "smartLock",
+		"nukiId": 705367053, "deviceName": "Front door", "latitude": 52.5, "longitude": 13.25,
+		"autoUnlatch": true, "pairingEnabled": true, "buttonEnabled": true, "ledEnabled": true, "ledBrightness": 3,
+		"currentTime": "2022-10-19T09:30:42", "timezoneOffset": 120, "dstMode": "european",
+		"hasFob": true, "fobAction1": 1, "fobAction2": 2, "fobAction3": 3, "singleLock": false,
+		"advertisingMode": "normal", "hasKeypad": true, "firmwareVersion": "3.10.2", "hardwareRevision": "5.0",
+		"homeKitStatus": "enabled", "timeZoneId": 37
+	}`)
+}
+
+func TestConfigCommand_JSON_Opener(t *testing.T) {
+	payload := testConfigPayload(72)
+	copy(payload[44:], []byte{0x01, 0x00, 0x01, 0x00})
+	copy(payload[48:], []byte{0xE6, 0x07, 0x0A, 0x13, 0x09, 0x1E, 0x2A, 0xC4, 0xFF, 0x00})
+	copy(payload[58:], []byte{0x00, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x01, 0x07, 0x04, 0x03, 0x00, 0xFF, 0xFF})
+	config := NewCommand(IdConfig, payload).AsConfigCommand()
+
+	assertJSONRoundTrip(t, config, `{
+		"id": 21, "name": "Config", "type": "opener",
+		"nukiId": 705367053, "deviceName": "Front door", "latitude": 52.5, "longitude": 13.25,
+		"capabilities": "both", "pairingEnabled": false, "buttonEnabled": true, "ledEnabled": false,
+		"currentTime": "2022-10-19T09:30:42", "timezoneOffset": -60, "dstMode": "disabled",
+		"hasFob": false, "fobAction1": 0, "fobAction2": 0, "fobAction3": 0, "operationMode": "digitalIntercomSiedle",
+		"advertisingMode": "slow", "hasKeypad": false, "firmwareVersion": "1.7.4", "hardwareRevision": "3.0",
+		"timeZoneId": 65535
+	}`)
+}
+
+func TestConfigCommand_JSON_Unknown(t *testing.T) {
+	config := NewCommand(IdConfig, testConfigPayload(73)).AsConfigCommand()
+
+	raw, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Contains(t, string(raw), `"payload":"0d0c0b2a`)
+
+	var result ConfigCommand
+	assert.NoError(t, json.Unmarshal(raw, &result))
+	assert.Equal(t, config, result)
+}
+
+func TestConfigCommand_JSON_NaN(t *testing.T) {
+	payload := testConfigPayload(72)
+	binary.LittleEndian.PutUint32(payload[36:40], math.Float32bits(float32(math.NaN())))
+	config := NewCommand(IdConfig, payload).AsConfigCommand()
+
+	raw, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Contains(t, string(raw), `"latitude":null`)
+}
+
+func testLogEntryPayload(loggingType LoggingType, data ...byte) []byte {
+	payload := make([]byte, 48)
+	binary.LittleEndian.PutUint32(payload[0:4], 42)
+	copy(payload[4:11], []byte{0xE6, 0x07, 0x0A, 0x13, 0x09, 0x1E, 0x2A})
+	binary.LittleEndian.PutUint32(payload[11:15], 7)
+	copy(payload[15:47], "Nuki Fob")
+	payload[47] = byte(loggingType)
+	return append(payload, data...)
+}
+
+func TestLogEntryCommand_JSON(t *testing.T) {
+	base := `"id": 50, "name": "LogEntry", "index": 42, "timestamp": "2022-10-19T09:30:42", "authId": 7, "authName": "Nuki Fob"`
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		expected string
+	}{
+		{"logging", testLogEntryPayload(LoggingTypeLoggingEnabledDisabled, 0x01),
+			`"type": "loggingEnabledDisabled", "loggingEnabled": true`},
+		{"lock action", testLogEntryPayload(LoggingTypeLockAction, 0x02, 0x06, 0x00, 0x01),
+			`"type": "lockAction", "lockAction": "lock", "trigger": "autoLock", "flags": 0, "completionStatus": "motorBlocked"`},
+		{"calibration", testLogEntryPayload(LoggingTypeCalibration, 0x00, 0x00, 0x01, 0x00),
+			`"type": "calibration", "lockAction": "0x00", "trigger": "system", "flags": 1, "completionStatus": "success"`},
+		{"keypad", testLogEntryPayload(LoggingTypeKeypadAction, 0x01, 0x02, 0x00, 0x39, 0x05),
+			`"type": "keypadAction", "lockAction": "unlock", "source": 2, "completionStatus": "success", "codeId": 1337`},
+		{"door sensor", testLogEntryPayload(LoggingTypeDoorSensor, 0x02),
+			`"type": "doorSensor", "doorSensorEvent": "jammed"`},
+		{"door sensor logging", testLogEntryPayload(LoggingTypeDoorSensorLoggingEnabledDisabled, 0x00),
+			`"type": "doorSensorLoggingEnabledDisabled", "loggingEnabled": true`},
+		{"truncated", testLogEntryPayload(LoggingTypeLockAction, 0x02, 0x06),
+			`"type": "lockAction", "extra": "0206"`},
+		{"unknown", testLogEntryPayload(LoggingType(0x42), 0x01, 0x02),
+			`"type": "0x42", "extra": "0102"`},
+		{"trailing bytes", testLogEntryPayload(LoggingTypeDoorSensor, 0x01, 0xFF),
+			`"type": "doorSensor", "doorSensorEvent": "closed", "extra": "ff"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logEntry := NewCommand(IdLogEntry, test.payload).AsLogEntryCommand()
+			assertJSONRoundTrip(t, logEntry, "{"+base+","+test.expected+"}")
+		})
+	}
+}
@@ -20,12 +20,16 @@ const (
 
 	LockActionFlagAutoUnlock = 0b0000_0001
 	LockActionFlagForce      = 0b0000_0010
+
+	// NameSuffixLength is the (fixed) length of the name suffix of a lock action. Shorter suffixes will be padded with
+	// zeros, longer ones will be truncated.
+	NameSuffixLength = 20
 )
 
 func NewLockAction(action LockAction, appId uint32, flags uint8, nameSuffix *string, nonce []byte) Command {
 	payloadLen := 1 + 4 + 1 + 32
 	if nameSuffix != nil {
-		payloadLen += 20
+		payloadLen += NameSuffixLength
 	}
 
 	payload := make([]byte, 0, payloadLen)
@@ -39,10 +43,10 @@ func NewLockAction(action LockAction, appId uint32, flags uint8, nameSuffix *str
 
 	if nameSuffix != nil {
 		n := *nameSuffix
-		if len(n) > 20 {
-			n = n[:20]
-		} else if len(n) < 20 {
-			n += strings.Repeat("\x00", 20-len(n))
+		if len(n) > NameSuffixLength {
+			n = n[:NameSuffixLength]
+		} else if len(n) < NameSuffixLength {
+			n += strings.Repeat("\x00", NameSuffixLength-len(n))
 		}
 
 		payload = append(payload, n...)
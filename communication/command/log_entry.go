@@ -3,6 +3,7 @@ package command
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -19,6 +20,17 @@ const (
 	LoggingTypeDoorSensorLoggingEnabledDisabled = LoggingType(0x07)
 )
 
+// logEntryLength contains the payload length of each logging type.
+var logEntryLength = map[LoggingType]int{
+	LoggingTypeLoggingEnabledDisabled:           49,
+	LoggingTypeLockAction:                       52,
+	LoggingTypeCalibration:                      52,
+	LoggingTypeInitializationRun:                52,
+	LoggingTypeKeypadAction:                     53,
+	LoggingTypeDoorSensor:                       49,
+	LoggingTypeDoorSensorLoggingEnabledDisabled: 49,
+}
+
 type LogEntryCommand Command
 type LogEntryLogging Command
 type LogEntryLockAction Command
@@ -26,71 +38,101 @@ type LogEntryKeypadAction Command
 type LogEntryDoorSensor Command
 type LogEntryDoorSensorLogging Command
 
+// AsLogEntryCommand returns the command as LogEntryCommand. Returns nil if the command is not a valid LogEntryCommand (see ParseLogEntryCommand).
 func (c Command) AsLogEntryCommand() LogEntryCommand {
-	if !c.Is(IdLogEntry) {
+	result, err := c.ParseLogEntryCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseLogEntryCommand returns the command as LogEntryCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseLogEntryCommand() (LogEntryCommand, error) {
+	if err := c.parse(IdLogEntry); err != nil {
+		return nil, err
+	}
+	return LogEntryCommand(c), nil
+}
 
-	return LogEntryCommand(c)
+// Validate checks if the command is a valid LogEntryCommand.
+func (l LogEntryCommand) Validate() error {
+	return Command(l).parse(IdLogEntry)
+}
+
+// hasPayload checks if the payload has at least the given length.
+func (l LogEntryCommand) hasPayload(length int) bool {
+	return len(Command(l).Payload()) >= length
 }
 
 func (l LogEntryCommand) Index() uint32 {
-	return binary.LittleEndian.Uint32(Command(l).Payload()[0:4])
+	return binary.LittleEndian.Uint32(Command(l).bytesAt(0, 4))
 }
 
 func (l LogEntryCommand) Timestamp() time.Time {
 	return time.Date(
-		int(binary.LittleEndian.Uint16(Command(l).Payload()[4:6])),
-		time.Month(Command(l).Payload()[6]),
-		int(Command(l).Payload()[7]),
-		int(Command(l).Payload()[8]),
-		int(Command(l).Payload()[9]),
-		int(Command(l).Payload()[10]),
+		int(binary.LittleEndian.Uint16(Command(l).bytesAt(4, 6))),
+		time.Month(Command(l).byteAt(6)),
+		int(Command(l).byteAt(7)),
+		int(Command(l).byteAt(8)),
+		int(Command(l).byteAt(9)),
+		int(Command(l).byteAt(10)),
 		0,
 		time.UTC,
 	)
 }
 
+// TimestampIn returns the timestamp in the given location. For the local time of the device use the location of its
+// config (see ConfigCommand.Location).
+func (l LogEntryCommand) TimestampIn(location *time.Location) time.Time {
+	return l.Timestamp().In(location)
+}
+
 func (l LogEntryCommand) AuthId() uint32 {
-	return binary.LittleEndian.Uint32(Command(l).Payload()[11:15])
+	return binary.LittleEndian.Uint32(Command(l).bytesAt(11, 15))
 }
 
 func (l LogEntryCommand) Name() string {
-	return string(Command(l).Payload()[15:47])
+	return string(Command(l).bytesAt(15, 47))
 }
 
 func (l LogEntryCommand) Type() LoggingType {
-	return LoggingType(Command(l).Payload()[47])
+	return LoggingType(Command(l).byteAt(47))
 }
 
 func (l LogEntryCommand) String() string {
 	var part string
 	var logType string
-	switch l.Type() {
-	case LoggingTypeLoggingEnabledDisabled:
-		part = l.AsLogging().String()
-		logType = "Logging Enabled/Disabled"
-	case LoggingTypeLockAction:
-		part = l.AsLockAction().String()
-		logType = "Lock action"
-	case LoggingTypeCalibration:
-		part = l.AsLockAction().String()
-		logType = "Calibration"
-	case LoggingTypeInitializationRun:
-		part = l.AsLockAction().String()
-		logType = "Initialization run"
-	case LoggingTypeKeypadAction:
-		part = l.AsKeypadAction().String()
-		logType = "Keypad action"
-	case LoggingTypeDoorSensor:
-		part = l.AsDoorSensor().String()
-		logType = "Door sensor"
-	case LoggingTypeDoorSensorLoggingEnabledDisabled:
-		part = l.AsDoorSensorLogging().String()
-		logType = "Door sensor logging"
-	default:
-		part = hex.EncodeToString(Command(l).Payload()[48:])
-		logType = "Unknown"
+	if l.hasPayload(logEntryLength[l.Type()]) {
+		switch l.Type() {
+		case LoggingTypeLoggingEnabledDisabled:
+			part = l.AsLogging().String()
+			logType = "Logging Enabled/Disabled"
+		case LoggingTypeLockAction:
+			part = l.AsLockAction().String()
+			logType = "Lock action"
+		case LoggingTypeCalibration:
+			part = l.AsLockAction().String()
+			logType = "Calibration"
+		case LoggingTypeInitializationRun:
+			part = l.AsLockAction().String()
+			logType = "Initialization run"
+		case LoggingTypeKeypadAction:
+			part = l.AsKeypadAction().String()
+			logType = "Keypad action"
+		case LoggingTypeDoorSensor:
+			part = l.AsDoorSensor().String()
+			logType = "Door sensor"
+		case LoggingTypeDoorSensorLoggingEnabledDisabled:
+			part = l.AsDoorSensorLogging().String()
+			logType = "Door sensor logging"
+		default:
+			part = hex.EncodeToString(Command(l).bytesFrom(48))
+			logType = "Unknown"
+		}
+	} else {
+		part = hex.EncodeToString(Command(l).bytesFrom(48))
+		logType = "Truncated"
 	}
 
 	return fmt.Sprintf("[%d][%s][%d][%s]: %s > %s",
@@ -104,7 +146,7 @@ func (l LogEntryCommand) String() string {
 }
 
 func (l LogEntryCommand) AsLogging() LogEntryLogging {
-	if l.Type() != LoggingTypeLoggingEnabledDisabled {
+	if l.Type() != LoggingTypeLoggingEnabledDisabled || !l.hasPayload(logEntryLength[l.Type()]) {
 		return nil
 	}
 
@@ -112,7 +154,7 @@ func (l LogEntryCommand) AsLogging() LogEntryLogging {
 }
 
 func (l LogEntryLogging) IsLoggingEnabled() bool {
-	return Command(l).Payload()[48] == 0x01
+	return Command(l).byteAt(48) == 0x01
 }
 
 func (l LogEntryLogging) String() string {
@@ -124,7 +166,8 @@ func (l LogEntryLogging) String() string {
 func (l LogEntryCommand) AsLockAction() LogEntryLockAction {
 	if l.Type() != LoggingTypeLockAction &&
 		l.Type() != LoggingTypeCalibration &&
-		l.Type() != LoggingTypeInitializationRun {
+		l.Type() != LoggingTypeInitializationRun ||
+		!l.hasPayload(logEntryLength[l.Type()]) {
 		return nil
 	}
 
@@ -132,32 +175,32 @@ func (l LogEntryCommand) AsLockAction() LogEntryLockAction {
 }
 
 func (l LogEntryLockAction) LockAction() LockAction {
-	return LockAction(Command(l).Payload()[48])
+	return LockAction(Command(l).byteAt(48))
 }
 
 func (l LogEntryLockAction) Trigger() Trigger {
-	return Trigger(Command(l).Payload()[49])
+	return Trigger(Command(l).byteAt(49))
 }
 
 func (l LogEntryLockAction) Flags() uint8 {
-	return Command(l).Payload()[50]
+	return Command(l).byteAt(50)
 }
 
 func (l LogEntryLockAction) CompletionStatus() uint8 {
-	return Command(l).Payload()[51]
+	return Command(l).byteAt(51)
 }
 
 func (l LogEntryLockAction) String() string {
-	return fmt.Sprintf("LockAction: 0x%02x; Trigger: 0x%02x; Flags: 0x%02x; Completion status: 0x%02x",
+	return fmt.Sprintf("LockAction: %s; Trigger: %s; Flags: 0x%02x; Completion status: %s",
 		l.LockAction(),
 		l.Trigger(),
 		l.Flags(),
-		l.CompletionStatus(),
+		lockActionCompletionStatusNames.format(l.CompletionStatus()),
 	)
 }
 
 func (l LogEntryCommand) AsKeypadAction() LogEntryKeypadAction {
-	if l.Type() != LoggingTypeKeypadAction {
+	if l.Type() != LoggingTypeKeypadAction || !l.hasPayload(logEntryLength[l.Type()]) {
 		return nil
 	}
 
@@ -165,32 +208,32 @@ func (l LogEntryCommand) AsKeypadAction() LogEntryKeypadAction {
 }
 
 func (l LogEntryKeypadAction) LockAction() LockAction {
-	return LockAction(Command(l).Payload()[48])
+	return LockAction(Command(l).byteAt(48))
 }
 
 func (l LogEntryKeypadAction) Source() uint8 {
-	return Command(l).Payload()[49]
+	return Command(l).byteAt(49)
 }
 
 func (l LogEntryKeypadAction) CompletionStatus() uint8 {
-	return Command(l).Payload()[50]
+	return Command(l).byteAt(50)
 }
 
 func (l LogEntryKeypadAction) CodeId() uint16 {
-	return binary.LittleEndian.Uint16(Command(l).Payload()[51:53])
+	return binary.LittleEndian.Uint16(Command(l).bytesAt(51, 53))
 }
 
 func (l LogEntryKeypadAction) String() string {
-	return fmt.Sprintf("LockAction: 0x%02x; Source: 0x%02x; Completion status: 0x%02x; CodeId: %d",
+	return fmt.Sprintf("LockAction: %s; Source: 0x%02x; Completion status: %s; CodeId: %d",
 		l.LockAction(),
 		l.Source(),
-		l.CompletionStatus(),
+		lockActionCompletionStatusNames.format(l.CompletionStatus()),
 		l.CodeId(),
 	)
 }
 
 func (l LogEntryCommand) AsDoorSensor() LogEntryDoorSensor {
-	if l.Type() != LoggingTypeDoorSensor {
+	if l.Type() != LoggingTypeDoorSensor || !l.hasPayload(logEntryLength[l.Type()]) {
 		return nil
 	}
 
@@ -198,15 +241,15 @@ func (l LogEntryCommand) AsDoorSensor() LogEntryDoorSensor {
 }
 
 func (l LogEntryDoorSensor) IsDoorOpened() bool {
-	return Command(l).Payload()[48] == 0x00
+	return Command(l).byteAt(48) == 0x00
 }
 
 func (l LogEntryDoorSensor) IsDoorClosed() bool {
-	return Command(l).Payload()[48] == 0x01
+	return Command(l).byteAt(48) == 0x01
 }
 
 func (l LogEntryDoorSensor) IsSensorJammed() bool {
-	return Command(l).Payload()[48] == 0x02
+	return Command(l).byteAt(48) == 0x02
 }
 
 func (l LogEntryDoorSensor) String() string {
@@ -218,7 +261,7 @@ func (l LogEntryDoorSensor) String() string {
 }
 
 func (l LogEntryCommand) AsDoorSensorLogging() LogEntryDoorSensorLogging {
-	if l.Type() != LoggingTypeDoorSensorLoggingEnabledDisabled {
+	if l.Type() != LoggingTypeDoorSensorLoggingEnabledDisabled || !l.hasPayload(logEntryLength[l.Type()]) {
 		return nil
 	}
 
@@ -226,7 +269,7 @@ func (l LogEntryCommand) AsDoorSensorLogging() LogEntryDoorSensorLogging {
 }
 
 func (l LogEntryDoorSensorLogging) IsLoggingEnabled() bool {
-	return Command(l).Payload()[48] == 0x00
+	return Command(l).byteAt(48) == 0x00
 }
 
 func (l LogEntryDoorSensorLogging) String() string {
@@ -234,3 +277,152 @@ func (l LogEntryDoorSensorLogging) String() string {
 		l.IsLoggingEnabled(),
 	)
 }
+
+func (l LogEntryCommand) Id() Id {
+	return IdLogEntry
+}
+
+// logEntryJSON is the json representation of a LogEntryCommand. The type specific fields are only set for the
+// corresponding logging type.
+type logEntryJSON struct {
+	Id               Id      `json:"id"`
+	Name             string  `json:"name"`
+	Index            uint32  `json:"index"`
+	Timestamp        string  `json:"timestamp"`
+	AuthId           uint32  `json:"authId"`
+	AuthName         string  `json:"authName"`
+	Type             string  `json:"type"`
+	LoggingEnabled   *bool   `json:"loggingEnabled,omitempty"`
+	LockAction       *string `json:"lockAction,omitempty"`
+	Trigger          *string `json:"trigger,omitempty"`
+	Source           *uint8  `json:"source,omitempty"`
+	Flags            *uint8  `json:"flags,omitempty"`
+	CompletionStatus *string `json:"completionStatus,omitempty"`
+	CodeId           *uint16 `json:"codeId,omitempty"`
+	DoorSensorEvent  *string `json:"doorSensorEvent,omitempty"`
+	// Extra contains the trailing bytes which are not decoded (for example of unknown or truncated entries).
+	Extra string `json:"extra,omitempty"`
+	// Payload is only used for unmarshalling the raw representation (see RawMessage).
+	Payload *string `json:"payload,omitempty"`
+}
+
+// MarshalJSON returns the log entry as json object with named enums (for example "type":"lockAction").
+func (l LogEntryCommand) MarshalJSON() ([]byte, error) {
+	if l.Validate() != nil {
+		return marshalRawJSON(Command(l))
+	}
+
+	payload := Command(l).Payload()
+	result := logEntryJSON{
+		Id:        l.Id(),
+		Name:      l.Id().Name(),
+		Index:     l.Index(),
+		Timestamp: formatDateTime(payload, 4),
+		AuthId:    l.AuthId(),
+		AuthName:  formatName(payload[15:47]),
+		Type:      l.Type().String(),
+	}
+
+	decoded := 48
+	if length, ok := logEntryLength[l.Type()]; ok && l.hasPayload(length) {
+		decoded = length
+
+		switch l.Type() {
+		case LoggingTypeLoggingEnabledDisabled:
+			result.LoggingEnabled = boolPtr(l.AsLogging().IsLoggingEnabled())
+		case LoggingTypeLockAction, LoggingTypeCalibration, LoggingTypeInitializationRun:
+			lockAction := l.AsLockAction()
+			result.LockAction = stringPtr(lockAction.LockAction().String())
+			result.Trigger = stringPtr(lockAction.Trigger().String())
+			result.Flags = uint8Ptr(lockAction.Flags())
+			result.CompletionStatus = stringPtr(lockActionCompletionStatusNames.format(lockAction.CompletionStatus()))
+		case LoggingTypeKeypadAction:
+			keypadAction := l.AsKeypadAction()
+			codeId := keypadAction.CodeId()
+			result.LockAction = stringPtr(keypadAction.LockAction().String())
+			result.Source = uint8Ptr(keypadAction.Source())
+			result.CompletionStatus = stringPtr(lockActionCompletionStatusNames.format(keypadAction.CompletionStatus()))
+			result.CodeId = &codeId
+		case LoggingTypeDoorSensor:
+			result.DoorSensorEvent = stringPtr(doorSensorEventNames.format(payload[48]))
+		case LoggingTypeDoorSensorLoggingEnabledDisabled:
+			result.LoggingEnabled = boolPtr(l.AsDoorSensorLogging().IsLoggingEnabled())
+		}
+	}
+	result.Extra = hex.EncodeToString(payload[decoded:])
+
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON converts the json representation (see MarshalJSON) back into the log entry command.
+func (l *LogEntryCommand) UnmarshalJSON(data []byte) error {
+	var j logEntryJSON
+	if err := unmarshalCommandJSON(data, IdLogEntry, &j); err != nil {
+		return err
+	}
+
+	var cmd Command
+	var err error
+	if j.Payload != nil {
+		cmd, err = unmarshalRawJSON(IdLogEntry, *j.Payload)
+	} else {
+		cmd, err = j.command()
+	}
+	if err != nil {
+		return err
+	}
+
+	logEntry, err := cmd.ParseLogEntryCommand()
+	if err != nil {
+		return err
+	}
+	*l = logEntry
+	return nil
+}
+
+func (j logEntryJSON) command() (Command, error) {
+	w := payloadWriter{}
+	w.uint32(j.Index)
+	w.dateTime(j.Timestamp)
+	w.uint32(j.AuthId)
+	w.name(j.AuthName)
+	w.enum(loggingTypeNames, j.Type)
+
+	loggingType := LoggingType(0)
+	if len(w.payload) == 48 {
+		loggingType = LoggingType(w.payload[47])
+	}
+
+	switch loggingType {
+	case LoggingTypeLoggingEnabledDisabled:
+		if j.LoggingEnabled != nil {
+			w.bool(*j.LoggingEnabled)
+		}
+	case LoggingTypeLockAction, LoggingTypeCalibration, LoggingTypeInitializationRun:
+		if j.LockAction != nil {
+			w.enum(lockActionNames.names(StatesTypeSmartLock), *j.LockAction)
+			w.enum(triggerNames.names(StatesTypeUnknown), valueOf(j.Trigger))
+			w.uint8(valueOf(j.Flags))
+			w.enum(lockActionCompletionStatusNames, valueOf(j.CompletionStatus))
+		}
+	case LoggingTypeKeypadAction:
+		if j.LockAction != nil {
+			w.enum(lockActionNames.names(StatesTypeSmartLock), *j.LockAction)
+			w.uint8(valueOf(j.Source))
+			w.enum(lockActionCompletionStatusNames, valueOf(j.CompletionStatus))
+			w.uint16(valueOf(j.CodeId))
+		}
+	case LoggingTypeDoorSensor:
+		if j.DoorSensorEvent != nil {
+			w.enum(doorSensorEventNames, *j.DoorSensorEvent)
+		}
+	case LoggingTypeDoorSensorLoggingEnabledDisabled:
+		if j.LoggingEnabled != nil {
+			//disabled is indicated by 0x01 for the door sensor logging
+			w.bool(!*j.LoggingEnabled)
+		}
+	}
+	w.hex(j.Extra)
+
+	return w.command(IdLogEntry)
+}
@@ -2,33 +2,48 @@ package command
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 )
 
 type LogEntryCountCommand Command
 
+// AsLogEntriesCountCommand returns the command as LogEntryCountCommand. Returns nil if the command is not a valid LogEntryCountCommand (see ParseLogEntriesCountCommand).
 func (c Command) AsLogEntriesCountCommand() LogEntryCountCommand {
-	if !c.Is(IdLogEntryCount) {
+	result, err := c.ParseLogEntriesCountCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseLogEntriesCountCommand returns the command as LogEntryCountCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseLogEntriesCountCommand() (LogEntryCountCommand, error) {
+	if err := c.parse(IdLogEntryCount); err != nil {
+		return nil, err
+	}
+	return LogEntryCountCommand(c), nil
+}
 
-	return LogEntryCountCommand(c)
+// Validate checks if the command is a valid LogEntryCountCommand.
+func (l LogEntryCountCommand) Validate() error {
+	return Command(l).parse(IdLogEntryCount)
 }
 
 func (l LogEntryCountCommand) IsLoggingEnabled() bool {
-	return Command(l).Payload()[0] == 0x01
+	return Command(l).byteAt(0) == 0x01
 }
 
 func (l LogEntryCountCommand) Count() uint16 {
-	return binary.LittleEndian.Uint16(Command(l).Payload()[1:3])
+	return binary.LittleEndian.Uint16(Command(l).bytesAt(1, 3))
 }
 
 func (l LogEntryCountCommand) IsDoorSensorEnabled() bool {
-	return Command(l).Payload()[3] == 0x01
+	return Command(l).byteAt(3) == 0x01
 }
 
 func (l LogEntryCountCommand) IsDoorSensorLoggingEnabled() bool {
-	return Command(l).Payload()[4] == 0x01
+	return Command(l).byteAt(4) == 0x01
 }
 
 func (l LogEntryCountCommand) String() string {
@@ -39,3 +54,18 @@ func (l LogEntryCountCommand) String() string {
 		l.IsDoorSensorLoggingEnabled(),
 	)
 }
+
+func (l LogEntryCountCommand) Id() Id {
+	return IdLogEntryCount
+}
+
+func (l LogEntryCountCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id                       Id     `json:"id"`
+		Name                     string `json:"name"`
+		LoggingEnabled           bool   `json:"loggingEnabled"`
+		Count                    uint16 `json:"count"`
+		DoorSensorEnabled        bool   `json:"doorSensorEnabled"`
+		DoorSensorLoggingEnabled bool   `json:"doorSensorLoggingEnabled"`
+	}{l.Id(), l.Id().Name(), l.IsLoggingEnabled(), l.Count(), l.IsDoorSensorEnabled(), l.IsDoorSensorLoggingEnabled()})
+}
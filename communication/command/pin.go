@@ -4,28 +4,89 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // InvalidPinError will be returned if the given pin is invalid
 var InvalidPinError = fmt.Errorf("the given pin is invalid")
 
-type Pin uint16
+// Pin is the security pin of a device. Older devices use a pin of 4 digits, newer ones (for example the smart lock
+// ultra) use a pin of 6 digits. The length of the pin decides how it is encoded (see AsByte).
+type Pin struct {
+	value  uint32
+	digits uint8
+}
 
+// NewPin parses the given decimal pin of 4 or 6 digits (like it is shown in the nuki app). For migration purposes,
+// pins which are prefixed with "0x" will be parsed as hex pin (see NewHexPin).
+//
+// Attention: earlier versions parsed the pin as hex value. So "1234" was sent as 0x1234 and is now sent as 1234. Pins
+// which were used with earlier versions must be prefixed with "0x" (see CHANGELOG.md).
 func NewPin(pin string) (Pin, error) {
-	if len(pin) != 4 || hex.DecodedLen(len(pin)) != 2 {
-		return 0, InvalidPinError
+	if strings.HasPrefix(pin, "0x") {
+		return NewHexPin(pin[2:])
+	}
+	if len(pin) != 4 && len(pin) != 6 {
+		return Pin{}, InvalidPinError
+	}
+	for _, digit := range pin {
+		if digit < '0' || digit > '9' {
+			return Pin{}, InvalidPinError
+		}
+	}
+
+	value, err := strconv.ParseUint(pin, 10, 32)
+	if err != nil {
+		return Pin{}, InvalidPinError
+	}
+	return Pin{value: uint32(value), digits: uint8(len(pin))}, nil
+}
+
+// NewHexPin parses the given pin of 4 hex characters. So "1234" results in the pin value 0x1234 (which is 4660).
+//
+// Deprecated: this was the behaviour of NewPin in earlier versions. It only exists for devices whose pin was set with
+// this value. Use NewPin instead.
+func NewHexPin(pin string) (Pin, error) {
+	if len(pin) != 4 {
+		return Pin{}, InvalidPinError
 	}
 	rawPin, err := hex.DecodeString(pin)
 	if err != nil {
-		return 0, InvalidPinError
+		return Pin{}, InvalidPinError
 	}
 
-	return Pin(binary.BigEndian.Uint16(rawPin)), nil
+	return Pin{value: uint32(binary.BigEndian.Uint16(rawPin)), digits: 4}, nil
 }
 
+// Value returns the numeric value of the pin.
+func (p Pin) Value() uint32 {
+	return p.value
+}
+
+// Digits returns the count of digits of the pin (4 or 6).
+func (p Pin) Digits() int {
+	if p.digits == 6 {
+		return 6
+	}
+	return 4
+}
+
+// String returns the pin with all of its digits.
+func (p Pin) String() string {
+	return fmt.Sprintf("%0*d", p.Digits(), p.value)
+}
+
+// AsByte returns the wire encoding of the pin: pins of 4 digits are encoded as uint16, pins of 6 digits as uint32
+// (both little endian).
 func (p Pin) AsByte() []byte {
-	pinAsByte := make([]byte, 2)
-	binary.LittleEndian.PutUint16(pinAsByte, uint16(p))
+	if p.Digits() == 6 {
+		pinAsByte := make([]byte, 4)
+		binary.LittleEndian.PutUint32(pinAsByte, p.value)
+		return pinAsByte
+	}
 
+	pinAsByte := make([]byte, 2)
+	binary.LittleEndian.PutUint16(pinAsByte, uint16(p.value))
 	return pinAsByte
 }
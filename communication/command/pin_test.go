@@ -0,0 +1,92 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func mustPin(pin string) Pin {
+	result, err := NewPin(pin)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func TestNewPin(t *testing.T) {
+	tests := []struct {
+		pin      string
+		value    uint32
+		digits   int
+		expected []byte
+	}{
+		{"1234", 1234, 4, []byte{0xD2, 0x04}},
+		{"0000", 0, 4, []byte{0x00, 0x00}},
+		{"9999", 9999, 4, []byte{0x0F, 0x27}},
+		{"0042", 42, 4, []byte{0x2A, 0x00}},
+		{"123456", 123456, 6, []byte{0x40, 0xE2, 0x01, 0x00}},
+		{"000042", 42, 6, []byte{0x2A, 0x00, 0x00, 0x00}},
+		{"0x1234", 0x1234, 4, []byte{0x34, 0x12}},
+		{"0xabcd", 0xABCD, 4, []byte{0xCD, 0xAB}},
+	}
+	for _, test := range tests {
+		t.Run(test.pin, func(t *testing.T) {
+			pin, err := NewPin(test.pin)
+			assert.NoError(t, err)
+			assert.Equal(t, test.value, pin.Value())
+			assert.Equal(t, test.digits, pin.Digits())
+			assert.Equal(t, test.expected, pin.AsByte())
+		})
+	}
+
+	assert.Equal(t, "0042", mustPin("0042").String())
+	assert.Equal(t, "000042", mustPin("000042").String())
+}
+
+func TestNewPin_Invalid(t *testing.T) {
+	for _, pin := range []string{"", "123", "12345", "1234567", "12a4", "-123", "+123", "1 34", "0x12", "0x12345", "0xzzzz"} {
+		_, err := NewPin(pin)
+		assert.ErrorIs(t, err, InvalidPinError, pin)
+	}
+}
+
+func TestNewHexPin(t *testing.T) {
+	pin, err := NewHexPin("1234")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x1234), pin.Value())
+	assert.Equal(t, []byte{0x34, 0x12}, pin.AsByte())
+
+	_, err = NewHexPin("12345")
+	assert.ErrorIs(t, err, InvalidPinError)
+	_, err = NewHexPin("zzzz")
+	assert.ErrorIs(t, err, InvalidPinError)
+}
+
+// TestPin_Frames checks the complete frame of a request with a pin. The frames are NOT captured from a device: they
+// follow the layout of the api documentation (nonce followed by the pin as little endian uint16 or uint32 for 6 digits)
+// and their crc was calculated independently (python, crc-ccitt). They should be replaced by captured frames as soon as
+// a trace of a device with a 6-digit pin is available.
+func TestPin_Frames(t *testing.T) {
+	nonce := make([]byte, 32)
+	for i := range nonce {
+		nonce[i] = byte(i + 1)
+	}
+
+	tests := []struct {
+		name     string
+		pin      string
+		expected string
+	}{
+		{"4 digits", "1234", "1D000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F20D204075E"},
+		{"6 digits", "123456", "1D000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F2040E201000057"},
+		{"hex", "0x1234", "1D000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F203412E496"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := NewRequestReboot(mustPin(test.pin), nonce)
+
+			assert.Equal(t, Command(decodeHex(test.expected)), cmd)
+			assert.True(t, cmd.CheckCRC())
+		})
+	}
+}
@@ -1,19 +1,55 @@
 package command
 
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
 func NewPublicKey(publicKey []byte) Command {
 	return NewCommand(IdPublicKey, publicKey)
 }
 
 type PublicKeyCommand Command
 
+// AsPublicKeyCommand returns the command as PublicKeyCommand. Returns nil if the command is not a valid PublicKeyCommand (see ParsePublicKeyCommand).
 func (c Command) AsPublicKeyCommand() PublicKeyCommand {
-	if !c.Is(IdPublicKey) {
+	result, err := c.ParsePublicKeyCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParsePublicKeyCommand returns the command as PublicKeyCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParsePublicKeyCommand() (PublicKeyCommand, error) {
+	if err := c.parse(IdPublicKey); err != nil {
+		return nil, err
+	}
+	return PublicKeyCommand(c), nil
+}
 
-	return PublicKeyCommand(c)
+// Validate checks if the command is a valid PublicKeyCommand.
+func (p PublicKeyCommand) Validate() error {
+	return Command(p).parse(IdPublicKey)
 }
 
 func (p PublicKeyCommand) PublicKey() []byte {
 	return Command(p).Payload()
 }
+
+func (p PublicKeyCommand) Id() Id {
+	return IdPublicKey
+}
+
+func (p PublicKeyCommand) String() string {
+	return fmt.Sprintf("Public key: %x", p.PublicKey())
+}
+
+func (p PublicKeyCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id        Id     `json:"id"`
+		Name      string `json:"name"`
+		PublicKey string `json:"publicKey"`
+	}{p.Id(), p.Id().Name(), hex.EncodeToString(p.PublicKey())})
+}
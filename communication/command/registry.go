@@ -0,0 +1,299 @@
+package command
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Direction describes who sends a command.
+type Direction uint8
+
+const (
+	// DirectionToDevice are commands which are sent by the client to the nuki device
+	DirectionToDevice = Direction(0x01)
+	// DirectionFromDevice are commands which are sent by the nuki device to the client
+	DirectionFromDevice = Direction(0x02)
+	// DirectionBoth are commands which are sent by both sides
+	DirectionBoth = DirectionToDevice | DirectionFromDevice
+)
+
+// Field describes one part of a command payload.
+type Field struct {
+	Name string
+	// Length is the count of bytes of this field. 0 means that the field contains all remaining bytes.
+	Length int
+}
+
+// Definition describes a command.
+type Definition struct {
+	Id        Id
+	Name      string
+	Direction Direction
+	// Schema describes the layout of the payload. It is nil if the layout is not known by this lib.
+	Schema []Field
+	// ShortSchema describes the layout of the payload without the optional fields (for example the name suffix of a
+	// lock action). It is nil if the command has no optional fields. See Layout.
+	ShortSchema []Field
+	// Decode converts a command into its typed message. It is nil if there is no typed message for the command.
+	Decode func(Command) (Message, error)
+}
+
+// Message is a decoded command (see Decode).
+type Message interface {
+	Id() Id
+	String() string
+	MarshalJSON() ([]byte, error)
+}
+
+var nonceField = Field{Name: "nonce", Length: 32}
+
+// pinField is always the last field: depending on the pin it has 2 or 4 bytes (see Pin.AsByte)
+var pinField = Field{Name: "pin", Length: 0}
+
+var registry = map[Id]Definition{}
+
+func register(definitions ...Definition) {
+	for _, definition := range definitions {
+		registry[definition.Id] = definition
+	}
+}
+
+func init() {
+	register(
+		Definition{Id: IdRequestData, Name: "RequestData", Direction: DirectionToDevice, Schema: []Field{{"commandId", 2}}},
+		Definition{Id: IdPublicKey, Name: "PublicKey", Direction: DirectionBoth, Schema: []Field{{"publicKey", 32}},
+			Decode: decodeAs(Command.ParsePublicKeyCommand)},
+		Definition{Id: IdChallenge, Name: "Challenge", Direction: DirectionFromDevice, Schema: []Field{nonceField},
+			Decode: decodeAs(Command.ParseChallengeCommand)},
+		Definition{Id: IdAuthorizationAuthenticator, Name: "AuthorizationAuthenticator", Direction: DirectionToDevice, Schema: []Field{{"authenticator", 32}}},
+		Definition{Id: IdAuthorizationData, Name: "AuthorizationData", Direction: DirectionToDevice, Schema: []Field{
+			{"authenticator", 32}, {"idType", 1}, {"id", 4}, {"name", 32}, nonceField,
+		}},
+		Definition{Id: IdAuthorizationID, Name: "AuthorizationID", Direction: DirectionFromDevice, Schema: []Field{
+			{"authenticator", 32}, {"authorizationId", 4}, {"uuid", 16}, nonceField,
+		}, Decode: decodeAs(Command.ParseAuthorizationIdCommand)},
+		Definition{Id: IdRemoveUserAuthorization, Name: "RemoveUserAuthorization", Direction: DirectionToDevice, Schema: []Field{
+			{"authorizationId", 4}, nonceField, pinField,
+		}},
+		Definition{Id: IdRequestAuthorizationEntries, Name: "RequestAuthorizationEntries", Direction: DirectionToDevice, Schema: []Field{
+			{"offset", 2}, {"count", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdAuthorizationEntry, Name: "AuthorizationEntry", Direction: DirectionFromDevice, Schema: []Field{
+			{"authorizationId", 4}, {"idType", 1}, {"name", 32}, {"enabled", 1}, {"remoteAllowed", 1}, {"dateCreated", 7},
+			{"dateLastActive", 7}, {"lockCount", 2}, {"timeLimited", 1}, {"allowedFromDate", 7}, {"allowedUntilDate", 7},
+			{"allowedWeekdays", 1}, {"allowedFromTime", 2}, {"allowedUntilTime", 2},
+		}},
+		Definition{Id: IdAuthorizationDataInvite, Name: "AuthorizationDataInvite", Direction: DirectionToDevice, Schema: []Field{
+			{"name", 32}, {"idType", 1}, {"sharedKey", 32}, {"remoteAllowed", 1}, {"timeLimited", 1}, {"allowedFromDate", 7},
+			{"allowedUntilDate", 7}, {"allowedWeekdays", 1}, {"allowedFromTime", 2}, {"allowedUntilTime", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdStates, Name: "States", Direction: DirectionFromDevice, Schema: []Field{
+			{"nukiState", 1}, {"lockState", 1}, {"trigger", 1}, {"currentTime", 7}, {"timezoneOffset", 2},
+			{"criticalBatteryState", 1}, {"configUpdateCount", 1}, {"lockNGoTimer", 1}, {"lastLockAction", 1},
+			{"lastLockActionTrigger", 1}, {"lastLockActionCompletionStatus", 1}, {"doorSensorState", 1}, {"deviceSpecific", 0},
+		}, Decode: decodeAs(Command.ParseStatesCommand)},
+		//the name suffix is optional: without it the nonce follows the flags (see NewLockAction)
+		Definition{Id: IdLockAction, Name: "LockAction", Direction: DirectionToDevice, Schema: []Field{
+			{"lockAction", 1}, {"appId", 4}, {"flags", 1}, {"nameSuffix", NameSuffixLength}, nonceField,
+		}, ShortSchema: []Field{
+			{"lockAction", 1}, {"appId", 4}, {"flags", 1}, nonceField,
+		}},
+		Definition{Id: IdStatus, Name: "Status", Direction: DirectionFromDevice, Schema: []Field{{"status", 1}},
+			Decode: decodeAs(Command.ParseStatusCommand)},
+		Definition{Id: IdMostRecentCommand, Name: "MostRecentCommand", Direction: DirectionFromDevice, Schema: []Field{{"commandId", 2}}},
+		Definition{Id: IdOpeningsClosingsSummary, Name: "OpeningsClosingsSummary", Direction: DirectionFromDevice, Schema: []Field{
+			{"openingsTotal", 2}, {"closingsTotal", 2}, {"openingsSinceLastRequest", 2}, {"closingsSinceLastRequest", 2},
+		}},
+		Definition{Id: IdBatteryReport, Name: "BatteryReport", Direction: DirectionFromDevice, Schema: []Field{
+			{"batteryDrain", 2}, {"batteryVoltage", 2}, {"criticalBatteryState", 1}, {"lockAction", 1}, {"startVoltage", 2},
+			{"lowestVoltage", 2}, {"lockDistance", 2}, {"startTemperature", 1}, {"maxTurnCurrent", 2}, {"batteryResistance", 2},
+		}},
+		Definition{Id: IdErrorReport, Name: "ErrorReport", Direction: DirectionFromDevice, Schema: []Field{{"errorCode", 1}, {"commandId", 2}},
+			Decode: decodeAs(Command.ParseErrorReportCommand)},
+		//layout of the smart lock: the opener has other device specific fields (see NewSetConfig)
+		Definition{Id: IdSetConfig, Name: "SetConfig", Direction: DirectionToDevice, Schema: []Field{
+			{"name", 32}, {"latitude", 4}, {"longitude", 4}, {"autoUnlatch", 1}, {"pairingEnabled", 1}, {"buttonEnabled", 1},
+			{"ledEnabled", 1}, {"ledBrightness", 1}, {"timezoneOffset", 2}, {"dstMode", 1}, {"fobAction1", 1}, {"fobAction2", 1},
+			{"fobAction3", 1}, {"singleLock", 1}, {"advertisingMode", 1}, {"timezoneId", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdRequestConfig, Name: "RequestConfig", Direction: DirectionToDevice, Schema: []Field{nonceField}},
+		Definition{Id: IdConfig, Name: "Config", Direction: DirectionFromDevice, Schema: []Field{
+			{"nukiId", 4}, {"name", 32}, {"latitude", 4}, {"longitude", 4}, {"deviceSpecific", 0},
+		}, Decode: decodeAs(Command.ParseConfigCommand)},
+		//the new pin has 2 or 4 bytes like the pin (see Pin.AsByte)
+		Definition{Id: IdSetSecurityPIN, Name: "SetSecurityPIN", Direction: DirectionToDevice, Schema: []Field{{"newPin", 2}, nonceField, pinField}},
+		Definition{Id: IdRequestCalibration, Name: "RequestCalibration", Direction: DirectionToDevice, Schema: []Field{nonceField, pinField}},
+		Definition{Id: IdRequestReboot, Name: "RequestReboot", Direction: DirectionToDevice, Schema: []Field{nonceField, pinField}},
+		Definition{Id: IdAuthorizationIDConfirmation, Name: "AuthorizationIDConfirmation", Direction: DirectionToDevice, Schema: []Field{
+			{"authenticator", 32}, {"authorizationId", 4},
+		}},
+		Definition{Id: IdAuthorizationIDInvite, Name: "AuthorizationIDInvite", Direction: DirectionFromDevice, Schema: []Field{{"authorizationId", 4}}},
+		Definition{Id: IdVerifySecurityPIN, Name: "VerifySecurityPIN", Direction: DirectionToDevice, Schema: []Field{nonceField, pinField}},
+		Definition{Id: IdUpdateTime, Name: "UpdateTime", Direction: DirectionToDevice, Schema: []Field{{"time", 7}, nonceField, pinField}},
+		Definition{Id: IdUpdateUserAuthorization, Name: "UpdateUserAuthorization", Direction: DirectionToDevice, Schema: []Field{
+			{"authorizationId", 4}, {"name", 32}, {"enabled", 1}, {"remoteAllowed", 1}, {"timeLimited", 1}, {"allowedFromDate", 7},
+			{"allowedUntilDate", 7}, {"allowedWeekdays", 1}, {"allowedFromTime", 2}, {"allowedUntilTime", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdAuthorizationEntryCount, Name: "AuthorizationEntryCount", Direction: DirectionFromDevice, Schema: []Field{{"count", 2}}},
+		Definition{Id: IdStartBusSignalRecording, Name: "StartBusSignalRecording", Direction: DirectionToDevice, Schema: []Field{nonceField, pinField}},
+		Definition{Id: IdRequestLogEntries, Name: "RequestLogEntries", Direction: DirectionToDevice, Schema: []Field{
+			{"startIndex", 4}, {"count", 2}, {"sortOrder", 1}, {"totalCount", 1}, nonceField, pinField,
+		}},
+		Definition{Id: IdLogEntry, Name: "LogEntry", Direction: DirectionFromDevice, Schema: []Field{
+			{"index", 4}, {"timestamp", 7}, {"authId", 4}, {"name", 32}, {"type", 1}, {"data", 0},
+		}, Decode: decodeAs(Command.ParseLogEntryCommand)},
+		Definition{Id: IdLogEntryCount, Name: "LogEntryCount", Direction: DirectionFromDevice, Schema: []Field{
+			{"loggingEnabled", 1}, {"count", 2}, {"doorSensorEnabled", 1}, {"doorSensorLoggingEnabled", 1},
+		}, Decode: decodeAs(Command.ParseLogEntriesCountCommand)},
+		Definition{Id: IdEnableLogging, Name: "EnableLogging", Direction: DirectionToDevice, Schema: []Field{{"enabled", 1}, nonceField, pinField}},
+		//layout of the smart lock: the opener has other device specific fields
+		Definition{Id: IdSetAdvancedConfig, Name: "SetAdvancedConfig", Direction: DirectionToDevice, Schema: []Field{
+			{"unlockedPositionOffsetDegrees", 2}, {"lockedPositionOffsetDegrees", 2}, {"singleLockedPositionOffsetDegrees", 2},
+			{"unlockedToLockedTransitionOffsetDegrees", 2}, {"lockNGoTimeout", 1}, {"singleButtonPressAction", 1},
+			{"doubleButtonPressAction", 1}, {"detachedCylinder", 1}, {"batteryType", 1}, {"automaticBatteryTypeDetection", 1},
+			{"unlatchDuration", 1}, {"autoLockTimeout", 2}, {"autoUnlockDisabled", 1}, {"nightModeEnabled", 1},
+			{"nightModeStartTime", 2}, {"nightModeEndTime", 2}, {"nightModeAutoLockEnabled", 1}, {"nightModeAutoUnlockDisabled", 1},
+			{"nightModeImmediateLockOnStart", 1}, {"autoLockEnabled", 1}, {"immediateAutoLockEnabled", 1}, {"autoUpdateEnabled", 1},
+			nonceField, pinField,
+		}},
+		Definition{Id: IdRequestAdvancedConfig, Name: "RequestAdvancedConfig", Direction: DirectionToDevice, Schema: []Field{nonceField}},
+		Definition{Id: IdAdvancedConfig, Name: "AdvancedConfig", Direction: DirectionFromDevice, Schema: []Field{{"deviceSpecific", 0}}},
+		Definition{Id: IdAddTimeControlEntry, Name: "AddTimeControlEntry", Direction: DirectionToDevice, Schema: []Field{
+			{"weekdays", 1}, {"time", 2}, {"lockAction", 1}, nonceField, pinField,
+		}},
+		Definition{Id: IdTimeControlEntryID, Name: "TimeControlEntryID", Direction: DirectionFromDevice, Schema: []Field{{"entryId", 1}}},
+		Definition{Id: IdRemoveTimeControlEntry, Name: "RemoveTimeControlEntry", Direction: DirectionToDevice, Schema: []Field{
+			{"entryId", 1}, nonceField, pinField,
+		}},
+		Definition{Id: IdRequestTimeControlEntries, Name: "RequestTimeControlEntries", Direction: DirectionToDevice, Schema: []Field{nonceField, pinField}},
+		Definition{Id: IdTimeControlEntryCount, Name: "TimeControlEntryCount", Direction: DirectionFromDevice, Schema: []Field{{"count", 1}}},
+		Definition{Id: IdTimeControlEntry, Name: "TimeControlEntry", Direction: DirectionFromDevice, Schema: []Field{
+			{"entryId", 1}, {"enabled", 1}, {"weekdays", 1}, {"time", 2}, {"lockAction", 1},
+		}},
+		Definition{Id: IdUpdateTimeControlEntry, Name: "UpdateTimeControlEntry", Direction: DirectionToDevice, Schema: []Field{
+			{"entryId", 1}, {"enabled", 1}, {"weekdays", 1}, {"time", 2}, {"lockAction", 1}, nonceField, pinField,
+		}},
+		Definition{Id: IdAddKeypadCode, Name: "AddKeypadCode", Direction: DirectionToDevice, Schema: []Field{
+			{"code", 4}, {"name", 20}, {"timeLimited", 1}, {"allowedFromDate", 7}, {"allowedUntilDate", 7}, {"allowedWeekdays", 1},
+			{"allowedFromTime", 2}, {"allowedUntilTime", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdKeypadCodeID, Name: "KeypadCodeID", Direction: DirectionFromDevice, Schema: []Field{{"codeId", 2}}},
+		Definition{Id: IdRequestKeypadCodes, Name: "RequestKeypadCodes", Direction: DirectionToDevice, Schema: []Field{
+			{"offset", 2}, {"count", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdKeypadCodeCount, Name: "KeypadCodeCount", Direction: DirectionFromDevice, Schema: []Field{{"count", 2}}},
+		Definition{Id: IdKeypadCode, Name: "KeypadCode", Direction: DirectionFromDevice, Schema: []Field{
+			{"codeId", 2}, {"enabled", 1}, {"code", 4}, {"name", 20}, {"dateCreated", 7}, {"dateLastActive", 7}, {"lockCount", 2},
+			{"timeLimited", 1}, {"allowedFromDate", 7}, {"allowedUntilDate", 7}, {"allowedWeekdays", 1}, {"allowedFromTime", 2},
+			{"allowedUntilTime", 2},
+		}},
+		Definition{Id: IdUpdateKeypadCode, Name: "UpdateKeypadCode", Direction: DirectionToDevice, Schema: []Field{
+			{"codeId", 2}, {"code", 4}, {"name", 20}, {"enabled", 1}, {"timeLimited", 1}, {"allowedFromDate", 7},
+			{"allowedUntilDate", 7}, {"allowedWeekdays", 1}, {"allowedFromTime", 2}, {"allowedUntilTime", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdRemoveKeypadCode, Name: "RemoveKeypadCode", Direction: DirectionToDevice, Schema: []Field{
+			{"codeId", 2}, nonceField, pinField,
+		}},
+		Definition{Id: IdKeypadAction, Name: "KeypadAction", Direction: DirectionToDevice, Schema: []Field{
+			{"source", 1}, {"code", 4}, {"action", 1}, nonceField,
+		}},
+		Definition{Id: IdContinuousModeAction, Name: "ContinuousModeAction", Direction: DirectionToDevice, Schema: []Field{
+			{"action", 1}, {"appId", 4}, {"flags", 1}, nonceField,
+		}},
+		//the name suffix is optional like the one of the lock action
+		Definition{Id: IdSimpleLockAction, Name: "SimpleLockAction", Direction: DirectionToDevice, Schema: []Field{
+			{"lockAction", 1}, {"appId", 4}, {"flags", 1}, {"nameSuffix", NameSuffixLength}, nonceField,
+		}, ShortSchema: []Field{
+			{"lockAction", 1}, {"appId", 4}, {"flags", 1}, nonceField,
+		}},
+	)
+}
+
+// decodeAs converts the given parse function into a decode function.
+func decodeAs[T Message](parse func(Command) (T, error)) func(Command) (Message, error) {
+	return func(c Command) (Message, error) {
+		message, err := parse(c)
+		if err != nil {
+			return nil, err
+		}
+		return message, nil
+	}
+}
+
+// Lookup returns the definition of the given command id.
+func Lookup(id Id) (Definition, bool) {
+	definition, ok := registry[id]
+	return definition, ok
+}
+
+// Layout returns the schema which describes the payload of the given command: the ShortSchema if the payload is too
+// short for the (full) Schema, otherwise the Schema.
+func (d Definition) Layout(c Command) []Field {
+	if d.ShortSchema != nil && len(c.Payload()) < schemaLength(d.Schema) {
+		return d.ShortSchema
+	}
+	return d.Schema
+}
+
+// schemaLength returns the count of bytes of all fields with a fixed length.
+func schemaLength(schema []Field) int {
+	length := 0
+	for _, field := range schema {
+		length += field.Length
+	}
+	return length
+}
+
+// Name returns the name of the command id. Unknown ids will be named by their hex value.
+func (i Id) Name() string {
+	if definition, ok := registry[i]; ok {
+		return definition.Name
+	}
+	return fmt.Sprintf("Unknown(0x%04X)", uint16(i))
+}
+
+// Decode converts the given command into its typed message (for example a StatesCommand). Commands without a typed
+// message (including unknown ones) will be returned as RawMessage. Returns an error if the command is invalid (see
+// Command.Validate).
+func Decode(c Command) (Message, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	definition, ok := registry[c.Id()]
+	if !ok || definition.Decode == nil {
+		return RawMessage(c), nil
+	}
+	return definition.Decode(c)
+}
+
+// RawMessage is a command without a typed message.
+type RawMessage Command
+
+func (r RawMessage) Id() Id {
+	return Command(r).Id()
+}
+
+func (r RawMessage) String() string {
+	return fmt.Sprintf("%s: %x", r.Id().Name(), Command(r).Payload())
+}
+
+func (r RawMessage) MarshalJSON() ([]byte, error) {
+	return marshalRawJSON(Command(r))
+}
+
+// rawJSON is the json representation of a command whose payload is not decoded.
+type rawJSON struct {
+	Id      Id     `json:"id"`
+	Name    string `json:"name"`
+	Payload string `json:"payload"`
+}
+
+func marshalRawJSON(c Command) ([]byte, error) {
+	return json.Marshal(rawJSON{
+		Id:      c.Id(),
+		Name:    c.Id().Name(),
+		Payload: hex.EncodeToString(c.Payload()),
+	})
+}
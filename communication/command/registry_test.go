@@ -0,0 +1,91 @@
+package command
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	message, err := Decode(NewCommand(IdStates, make([]byte, 21)))
+	assert.NoError(t, err)
+	assert.IsType(t, StatesCommand{}, message)
+	assert.Equal(t, IdStates, message.Id())
+
+	message, err = Decode(NewCommand(IdErrorReport, []byte{0x45, 0x0D, 0x00}))
+	assert.NoError(t, err)
+	assert.Equal(t, IdLockAction, message.(ErrorReportCommand).CommandId())
+	assert.Equal(t, "Error code: 0x45; Command: LockAction", message.String())
+}
+
+func TestDecode_Raw(t *testing.T) {
+	message, err := Decode(NewCommand(IdLockAction, []byte{0x01, 0x02}))
+	assert.NoError(t, err)
+	assert.IsType(t, RawMessage{}, message)
+	assert.Equal(t, "LockAction: 0102", message.String())
+
+	message, err = Decode(NewCommand(Id(0x4242), []byte{0x01}))
+	assert.NoError(t, err)
+	assert.Equal(t, Id(0x4242), message.Id())
+	assert.Equal(t, "Unknown(0x4242): 01", message.String())
+
+	raw, err := json.Marshal(message)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":16962,"name":"Unknown(0x4242)","payload":"01"}`, string(raw))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := Decode(NewCommand(IdStatus, nil))
+	assert.ErrorIs(t, err, BadLengthError)
+
+	_, err = Decode(Command{0x0E})
+	assert.ErrorIs(t, err, BadLengthError)
+}
+
+func TestLookup(t *testing.T) {
+	definition, ok := Lookup(IdConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "Config", definition.Name)
+	assert.Equal(t, DirectionFromDevice, definition.Direction)
+	assert.NotNil(t, definition.Decode)
+
+	_, ok = Lookup(Id(0x4242))
+	assert.False(t, ok)
+
+	for id, definition := range registry {
+		assert.Equal(t, id, definition.Id)
+		assert.NotEmpty(t, definition.Name)
+		assert.NotZero(t, definition.Direction)
+		assert.NotEmpty(t, definition.Schema, "schema of %s", definition.Name)
+		for i, field := range definition.Schema {
+			if field.Length == 0 {
+				assert.Equal(t, len(definition.Schema)-1, i, "only the last field of %s can have a variable length", definition.Name)
+			}
+		}
+	}
+}
+
+func TestMessage_MarshalJSON(t *testing.T) {
+	message, err := Decode(NewCommand(IdStatus, []byte{0x01}))
+	assert.NoError(t, err)
+
+	raw, err := json.Marshal(message)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":14,"name":"Status","status":1}`, string(raw))
+}
+
+func TestDefinition_Layout(t *testing.T) {
+	definition, _ := Lookup(IdLockAction)
+	nameSuffix := "Alice"
+
+	withSuffix := definition.Layout(NewLockAction(LockActionLock, 13, 0, &nameSuffix, make([]byte, 32)))
+	assert.Equal(t, definition.Schema, withSuffix)
+	assert.Equal(t, 1+4+1+NameSuffixLength+32, schemaLength(withSuffix))
+
+	withoutSuffix := definition.Layout(NewLockAction(LockActionLock, 13, 0, nil, make([]byte, 32)))
+	assert.Equal(t, definition.ShortSchema, withoutSuffix)
+	assert.Equal(t, 1+4+1+32, schemaLength(withoutSuffix))
+
+	definition, _ = Lookup(IdStates)
+	assert.Equal(t, definition.Schema, definition.Layout(NewCommand(IdStates, make([]byte, 3))))
+}
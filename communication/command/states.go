@@ -2,6 +2,8 @@ package command
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -12,6 +14,10 @@ type LockState uint8
 type Trigger uint8
 type DoorSensorState uint8
 
+// LockActionCompletionStatus is the completion status of the last lock action (see states and log entries). It differs
+// from the CompletionStatus of the status command.
+type LockActionCompletionStatus uint8
+
 const (
 	NukiStateUninitialized   = NukiState(0x00)
 	NukiStatePairingMode     = NukiState(0x01)
@@ -33,6 +39,18 @@ const (
 	DoorSensorStateDoorOpened       = DoorSensorState(0x03)
 	DoorSensorStateDoorStateUnknown = DoorSensorState(0x04)
 	DoorSensorStateCalibrating      = DoorSensorState(0x05)
+
+	LockActionCompletionStatusSuccess           = LockActionCompletionStatus(0x00)
+	LockActionCompletionStatusMotorBlocked      = LockActionCompletionStatus(0x01)
+	LockActionCompletionStatusCanceled          = LockActionCompletionStatus(0x02)
+	LockActionCompletionStatusTooRecent         = LockActionCompletionStatus(0x03)
+	LockActionCompletionStatusBusy              = LockActionCompletionStatus(0x04)
+	LockActionCompletionStatusLowMotorVoltage   = LockActionCompletionStatus(0x05)
+	LockActionCompletionStatusClutchFailure     = LockActionCompletionStatus(0x06)
+	LockActionCompletionStatusMotorPowerFailure = LockActionCompletionStatus(0x07)
+	LockActionCompletionStatusIncomplete        = LockActionCompletionStatus(0x08)
+	LockActionCompletionStatusOtherError        = LockActionCompletionStatus(0xFE)
+	LockActionCompletionStatusUnknown           = LockActionCompletionStatus(0xFF)
 )
 
 type StatesType uint8
@@ -68,14 +86,29 @@ const (
 	LockStateOpenerRTOActive = LockState(0x03)
 	LockStateOpenerOpen      = LockState(0x05)
 	LockStateOpenerOpening   = LockState(0x07)
+	LockStateOpenerBootRun   = LockState(0xFC)
 )
 
+// AsStatesCommand returns the command as StatesCommand. Returns nil if the command is not a valid StatesCommand (see ParseStatesCommand).
 func (c Command) AsStatesCommand() StatesCommand {
-	if !c.Is(IdKeyturnerStates) && !c.Is(IdOpenerStates) {
+	result, err := c.ParseStatesCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseStatesCommand returns the command as StatesCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseStatesCommand() (StatesCommand, error) {
+	if err := c.parse(IdStates); err != nil {
+		return nil, err
+	}
+	return StatesCommand(c), nil
+}
 
-	return StatesCommand(c)
+// Validate checks if the command is a valid StatesCommand.
+func (s StatesCommand) Validate() error {
+	return Command(s).parse(IdStates)
 }
 
 func (s StatesCommand) AsSmartLockStates() StatesSmartLockCommand {
@@ -102,77 +135,84 @@ func (s StatesCommand) Type() StatesType {
 }
 
 func (s StatesCommand) NukiState() NukiState {
-	return NukiState(Command(s).Payload()[0])
+	return NukiState(Command(s).byteAt(0))
 }
 
 func (s StatesCommand) LockState() LockState {
-	return LockState(Command(s).Payload()[1])
+	return LockState(Command(s).byteAt(1))
 }
 
 func (s StatesCommand) Trigger() Trigger {
-	return Trigger(Command(s).Payload()[2])
+	return Trigger(Command(s).byteAt(2))
 }
 
+// CurrentTime returns the current time of the device. The device reports its time in UTC, the returned time is
+// located in the zone of the timezone offset (see TimezoneOffset).
 func (s StatesCommand) CurrentTime() time.Time {
 	return time.Date(
-		int(binary.LittleEndian.Uint16(Command(s).Payload()[3:5])),
-		time.Month(Command(s).Payload()[5]),
-		int(Command(s).Payload()[6]),
-		int(Command(s).Payload()[7]),
-		int(Command(s).Payload()[8]),
-		int(Command(s).Payload()[9]),
+		int(binary.LittleEndian.Uint16(Command(s).bytesAt(3, 5))),
+		time.Month(Command(s).byteAt(5)),
+		int(Command(s).byteAt(6)),
+		int(Command(s).byteAt(7)),
+		int(Command(s).byteAt(8)),
+		int(Command(s).byteAt(9)),
 		0,
-		time.FixedZone("nuki", int(int16(binary.LittleEndian.Uint16(Command(s).Payload()[10:12])))),
-	)
+		time.UTC,
+	).In(time.FixedZone("nuki", int(s.TimezoneOffset().Seconds())))
+}
+
+// TimezoneOffset returns the offset of the device time zone to UTC.
+func (s StatesCommand) TimezoneOffset() time.Duration {
+	return time.Duration(int16(binary.LittleEndian.Uint16(Command(s).bytesAt(10, 12)))) * time.Minute
 }
 
 func (s StatesSmartLockCommand) CriticalBatteryState() (critical bool, charging bool, battery uint8) {
-	critical = (Command(s).Payload()[12] & 0b0000_0001) == 0b0000_0001
-	charging = (Command(s).Payload()[12] & 0b0000_0010) == 0b0000_0010
-	battery = (Command(s).Payload()[12] >> 2) * 2
+	critical = (Command(s).byteAt(12) & 0b0000_0001) == 0b0000_0001
+	charging = (Command(s).byteAt(12) & 0b0000_0010) == 0b0000_0010
+	battery = (Command(s).byteAt(12) >> 2) * 2
 
 	return
 }
 
 func (s StatesOpenerCommand) CriticalBatteryState() bool {
-	return (Command(s).Payload()[12] & 0b0000_0001) == 0b0000_0001
+	return (Command(s).byteAt(12) & 0b0000_0001) == 0b0000_0001
 }
 
 func (s StatesCommand) ConfigUpdateCount() uint8 {
-	return Command(s).Payload()[13]
+	return Command(s).byteAt(13)
 }
 
 func (s StatesSmartLockCommand) LockAndGoTimer() uint8 {
-	return Command(s).Payload()[14]
+	return Command(s).byteAt(14)
 }
 
 func (s StatesOpenerCommand) RingToOpenTimer() uint8 {
-	return Command(s).Payload()[14]
+	return Command(s).byteAt(14)
 }
 
 func (s StatesCommand) LastLockAction() LockAction {
-	return LockAction(Command(s).Payload()[15])
+	return LockAction(Command(s).byteAt(15))
 }
 
 func (s StatesCommand) LastLockActionTrigger() Trigger {
-	return Trigger(Command(s).Payload()[16])
+	return Trigger(Command(s).byteAt(16))
 }
 
-func (s StatesCommand) LastLockActionCompletionStatus() CompletionStatus {
-	return CompletionStatus(Command(s).Payload()[17])
+func (s StatesCommand) LastLockActionCompletionStatus() LockActionCompletionStatus {
+	return LockActionCompletionStatus(Command(s).byteAt(17))
 }
 
 func (s StatesCommand) DoorSensorState() DoorSensorState {
-	return DoorSensorState(Command(s).Payload()[18])
+	return DoorSensorState(Command(s).byteAt(18))
 }
 
 func (s StatesSmartLockCommand) NightModeActive() bool {
-	return Command(s).Payload()[19] != 0
+	return Command(s).byteAt(19) != 0
 }
 
 func (s StatesSmartLockCommand) AccessoryBatteryState() (supported bool, kpBatteryCritical bool) {
-	supported = (Command(s).Payload()[20] & 0b0000_0001) == 0b0000_0001
-	kpBatteryCritical = (Command(s).Payload()[20] & 0b0000_0010) == 0b0000_0010
+	supported = (Command(s).byteAt(20) & 0b0000_0001) == 0b0000_0001
+	kpBatteryCritical = (Command(s).byteAt(20) & 0b0000_0010) == 0b0000_0010
 
 	return
 }
@@ -186,17 +226,17 @@ func (s StatesCommand) String() string {
 	}
 
 	return fmt.Sprintf(
-		"Nuki-State: 0x%02x\nLock-State: 0x%02x\nTrigger: 0x%02x\nCurrent Time: %s"+
-			"\nConfig update count: %d\nLast Lockaction: 0x%02x\nLast Lockaction trigger: 0x%02x\nLast Lockaction completion status: 0x%02x"+
-			"\nDoor sensor state: 0x%02x\n%s",
-		s.NukiState(),
-		s.LockState(),
-		s.Trigger(),
+		"Nuki-State: %s\nLock-State: %s\nTrigger: %s\nCurrent Time: %s"+
+			"\nConfig update count: %d\nLast Lockaction: %s\nLast Lockaction trigger: %s\nLast Lockaction completion status: %s"+
+			"\nDoor sensor state: %s\n%s",
+		s.NukiState().Name(s.Type()),
+		s.LockState().Name(s.Type()),
+		s.Trigger().Name(s.Type()),
 		s.CurrentTime().Format(time.RFC3339),
 		s.ConfigUpdateCount(),
-		s.LastLockAction(),
-		s.LastLockActionTrigger(),
-		s.LastLockActionCompletionStatus(),
+		s.LastLockAction().Name(s.Type()),
+		s.LastLockActionTrigger().Name(s.Type()),
+		s.LastLockActionCompletionStatus().String(),
 		s.DoorSensorState(),
 		subPart,
 	)
@@ -223,3 +263,175 @@ func (s StatesOpenerCommand) String() string {
 		s.RingToOpenTimer(),
 	)
 }
+
+func (s StatesCommand) Id() Id {
+	return IdStates
+}
+
+// statesJSON is the json representation of a StatesCommand. The device specific fields are only set for the
+// corresponding device type.
+type statesJSON struct {
+	Id                             Id     `json:"id"`
+	Name                           string `json:"name"`
+	Type                           string `json:"type"`
+	NukiState                      string `json:"nukiState"`
+	LockState                      string `json:"lockState"`
+	Trigger                        string `json:"trigger"`
+	CurrentTime                    string `json:"currentTime"`
+	TimezoneOffset                 int16  `json:"timezoneOffset"`
+	BatteryCritical                bool   `json:"batteryCritical"`
+	BatteryCharging                *bool  `json:"batteryCharging,omitempty"`
+	BatteryPercentage              *uint8 `json:"batteryPercentage,omitempty"`
+	ConfigUpdateCount              uint8  `json:"configUpdateCount"`
+	LockNGoTimer                   *uint8 `json:"lockNGoTimer,omitempty"`
+	RingToOpenTimer                *uint8 `json:"ringToOpenTimer,omitempty"`
+	LastLockAction                 string `json:"lastLockAction"`
+	LastLockActionTrigger          string `json:"lastLockActionTrigger"`
+	LastLockActionCompletionStatus string `json:"lastLockActionCompletionStatus"`
+	DoorSensorState                string `json:"doorSensorState"`
+	NightModeActive                *bool  `json:"nightModeActive,omitempty"`
+	AccessoryBatterySupported      *bool  `json:"accessoryBatterySupported,omitempty"`
+	AccessoryBatteryCritical       *bool  `json:"accessoryBatteryCritical,omitempty"`
+	// Extra contains the trailing bytes which are not decoded (opener only).
+	Extra string `json:"extra,omitempty"`
+	// Payload is only used for unmarshalling the raw representation (see RawMessage).
+	Payload *string `json:"payload,omitempty"`
+}
+
+// MarshalJSON returns the states as json object with named enums (for example "lockState":"unlocked").
+func (s StatesCommand) MarshalJSON() ([]byte, error) {
+	if s.Validate() != nil {
+		return marshalRawJSON(Command(s))
+	}
+
+	payload := Command(s).Payload()
+	statesType := s.Type()
+	result := statesJSON{
+		Id:                             s.Id(),
+		Name:                           s.Id().Name(),
+		NukiState:                      s.NukiState().Name(statesType),
+		LockState:                      s.LockState().Name(statesType),
+		Trigger:                        s.Trigger().Name(statesType),
+		CurrentTime:                    formatDateTime(payload, 3),
+		TimezoneOffset:                 int16(binary.LittleEndian.Uint16(payload[10:12])),
+		BatteryCritical:                (payload[12] & 0b0000_0001) == 0b0000_0001,
+		ConfigUpdateCount:              s.ConfigUpdateCount(),
+		LastLockAction:                 s.LastLockAction().Name(statesType),
+		LastLockActionTrigger:          s.LastLockActionTrigger().Name(statesType),
+		LastLockActionCompletionStatus: s.LastLockActionCompletionStatus().String(),
+		DoorSensorState:                s.DoorSensorState().String(),
+	}
+
+	if smartLock := s.AsSmartLockStates(); smartLock != nil {
+		_, charging, percentage := smartLock.CriticalBatteryState()
+
+		result.Type = jsonTypeSmartLock
+		result.BatteryCharging = boolPtr(charging)
+		result.BatteryPercentage = uint8Ptr(percentage)
+		result.LockNGoTimer = uint8Ptr(smartLock.LockAndGoTimer())
+		if len(payload) > 19 {
+			result.NightModeActive = boolPtr(smartLock.NightModeActive())
+		}
+		if len(payload) > 20 {
+			supported, critical := smartLock.AccessoryBatteryState()
+			result.AccessoryBatterySupported = boolPtr(supported)
+			result.AccessoryBatteryCritical = boolPtr(critical)
+		}
+	} else if opener := s.AsOpenerStates(); opener != nil {
+		result.Type = jsonTypeOpener
+		result.RingToOpenTimer = uint8Ptr(opener.RingToOpenTimer())
+		result.Extra = hex.EncodeToString(payload[19:])
+	}
+
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON converts the json representation (see MarshalJSON) back into the states command.
+func (s *StatesCommand) UnmarshalJSON(data []byte) error {
+	var j statesJSON
+	if err := unmarshalCommandJSON(data, IdStates, &j); err != nil {
+		return err
+	}
+
+	var cmd Command
+	var err error
+	if j.Payload != nil {
+		cmd, err = unmarshalRawJSON(IdStates, *j.Payload)
+	} else {
+		cmd, err = j.command()
+	}
+	if err != nil {
+		return err
+	}
+
+	states, err := cmd.ParseStatesCommand()
+	if err != nil {
+		return err
+	}
+	*s = states
+	return nil
+}
+
+func (j statesJSON) command() (Command, error) {
+	var statesType StatesType
+	switch j.Type {
+	case jsonTypeSmartLock:
+		statesType = StatesTypeSmartLock
+	case jsonTypeOpener:
+		statesType = StatesTypeOpener
+	default:
+		return nil, fmt.Errorf("%w: unknown states type %q", InvalidJSONError, j.Type)
+	}
+
+	w := payloadWriter{}
+	w.enum(nukiStateNames.names(statesType), j.NukiState)
+	w.enum(lockStateNames.names(statesType), j.LockState)
+	w.enum(triggerNames.names(statesType), j.Trigger)
+	w.dateTime(j.CurrentTime)
+	w.uint16(uint16(j.TimezoneOffset))
+
+	battery := uint8(0)
+	if j.BatteryCritical {
+		battery |= 0b0000_0001
+	}
+	if valueOf(j.BatteryCharging) {
+		battery |= 0b0000_0010
+	}
+	w.uint8(battery | (valueOf(j.BatteryPercentage)/2)<<2)
+
+	w.uint8(j.ConfigUpdateCount)
+	if statesType == StatesTypeSmartLock {
+		w.uint8(valueOf(j.LockNGoTimer))
+	} else {
+		w.uint8(valueOf(j.RingToOpenTimer))
+	}
+	w.enum(lockActionNames.names(statesType), j.LastLockAction)
+	w.enum(triggerNames.names(statesType), j.LastLockActionTrigger)
+	w.enum(lockActionCompletionStatusNames, j.LastLockActionCompletionStatus)
+	w.enum(doorSensorStateNames, j.DoorSensorState)
+
+	if statesType == StatesTypeSmartLock {
+		hasAccessory := j.AccessoryBatterySupported != nil || j.AccessoryBatteryCritical != nil
+		if j.NightModeActive != nil || hasAccessory {
+			w.bool(valueOf(j.NightModeActive))
+		}
+		if hasAccessory {
+			accessory := uint8(0)
+			if valueOf(j.AccessoryBatterySupported) {
+				accessory |= 0b0000_0001
+			}
+			if valueOf(j.AccessoryBatteryCritical) {
+				accessory |= 0b0000_0010
+			}
+			w.uint8(accessory)
+		}
+	} else {
+		w.hex(j.Extra)
+		//openers are recognized by the length of the payload
+		for len(w.payload) < 22 {
+			w.uint8(0x00)
+		}
+	}
+
+	return w.command(IdStates)
+}
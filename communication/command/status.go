@@ -1,5 +1,10 @@
 package command
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type CompletionStatus uint8
 
 const (
@@ -9,22 +14,54 @@ const (
 
 type StatusCommand Command
 
+// AsStatusCommand returns the command as StatusCommand. Returns nil if the command is not a valid StatusCommand (see ParseStatusCommand).
 func (c Command) AsStatusCommand() StatusCommand {
-	if !c.Is(IdStatus) {
+	result, err := c.ParseStatusCommand()
+	if err != nil {
 		return nil
 	}
+	return result
+}
+
+// ParseStatusCommand returns the command as StatusCommand. Returns an error if the command has an other id or is too short.
+func (c Command) ParseStatusCommand() (StatusCommand, error) {
+	if err := c.parse(IdStatus); err != nil {
+		return nil, err
+	}
+	return StatusCommand(c), nil
+}
 
-	return StatusCommand(c)
+// Validate checks if the command is a valid StatusCommand.
+func (s StatusCommand) Validate() error {
+	return Command(s).parse(IdStatus)
 }
 
 func (s StatusCommand) Status() CompletionStatus {
-	return CompletionStatus(Command(s).Payload()[0])
+	return CompletionStatus(Command(s).byteAt(0))
 }
 
+// IsComplete returns true if the device reports the completion. An invalid (or nil) command is never complete.
 func (s StatusCommand) IsComplete() bool {
-	return s.Status() == CompletionStatusComplete
+	return s.Validate() == nil && s.Status() == CompletionStatusComplete
 }
 
+// IsAccepted returns true if the device has accepted the command. An invalid (or nil) command is never accepted.
 func (s StatusCommand) IsAccepted() bool {
-	return s.Status() == CompletionStatusAccepted
+	return s.Validate() == nil && s.Status() == CompletionStatusAccepted
+}
+
+func (s StatusCommand) Id() Id {
+	return IdStatus
+}
+
+func (s StatusCommand) String() string {
+	return fmt.Sprintf("Status: %s", s.Status())
+}
+
+func (s StatusCommand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Id     Id               `json:"id"`
+		Name   string           `json:"name"`
+		Status CompletionStatus `json:"status"`
+	}{s.Id(), s.Id().Name(), s.Status()})
 }
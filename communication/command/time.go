@@ -5,7 +5,10 @@ import (
 	"time"
 )
 
+// NewUpdateTime builds a command which sets the time of the device. The device expects its time in UTC, so the given
+// time will be converted.
 func NewUpdateTime(t time.Time, pin Pin, nonce []byte) Command {
+	t = t.UTC()
 	payload := make([]byte, 0, 7+len(nonce)+2)
 
 	yearAsByte := make([]byte, 2)
@@ -2,11 +2,19 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"time"
 )
 
+// UnknownTimeZoneError will be returned if a time zone id has no corresponding location.
+var UnknownTimeZoneError = fmt.Errorf("unknown time zone")
+
 type TimeZoneId uint16
 
+// TimeZoneIdNone means that no time zone is configured. The device only uses the timezone offset and dst mode.
+const TimeZoneIdNone = TimeZoneId(0xFFFF)
+
 var timeZoneIdMapping = map[TimeZoneId][]string{
 	0:  {"Africa/Cairo", "UTC+2", "EET", "false"},
 	1:  {"Africa/Lagos", "UTC+1", "WAT", "false"},
@@ -88,3 +96,70 @@ func (t TimeZoneId) DST() bool {
 func (t TimeZoneId) String() string {
 	return fmt.Sprintf("%s | %s | %s | %v", t.Name(), t.Offset(), t.Timezone(), t.DST())
 }
+
+// Location returns the location of the time zone. Returns an error if the time zone id is unknown or the location
+// can not be loaded (see time.LoadLocation).
+func (t TimeZoneId) Location() (*time.Location, error) {
+	name := t.Name()
+	if name == "" {
+		return nil, fmt.Errorf("%w: %d", UnknownTimeZoneError, t)
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", UnknownTimeZoneError, err.Error())
+	}
+	return location, nil
+}
+
+// TimeZoneIdOf returns the time zone id of the given location. If there is no time zone with the same name, the first
+// time zone which has the same offsets over the current year will be returned (for example Europe/Berlin for
+// Europe/Paris). Returns false if no time zone matches.
+func TimeZoneIdOf(location *time.Location) (TimeZoneId, bool) {
+	if location == nil {
+		return TimeZoneIdNone, false
+	}
+
+	ids := make([]TimeZoneId, 0, len(timeZoneIdMapping))
+	for id := range timeZoneIdMapping {
+		if id.Name() == location.String() {
+			return id, true
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	year := time.Now().Year()
+	for _, id := range ids {
+		candidate, err := id.Location()
+		if err == nil && sameOffsets(location, candidate, year) {
+			return id, true
+		}
+	}
+	return TimeZoneIdNone, false
+}
+
+// sameOffsets checks if both locations have the same offset on each day of the given year.
+func sameOffsets(a, b *time.Location, year int) bool {
+	for day := time.Date(year, time.January, 1, 12, 0, 0, 0, time.UTC); day.Year() == year; day = day.AddDate(0, 0, 1) {
+		_, offsetA := day.In(a).Zone()
+		_, offsetB := day.In(b).Zone()
+		if offsetA != offsetB {
+			return false
+		}
+	}
+	return true
+}
+
+// standardOffset returns the offset of the given location without daylight saving time. Also returns if the location
+// observes daylight saving time in the given year.
+func standardOffset(location *time.Location, year int) (offset time.Duration, dst bool) {
+	_, winter := time.Date(year, time.January, 1, 12, 0, 0, 0, time.UTC).In(location).Zone()
+	_, summer := time.Date(year, time.July, 1, 12, 0, 0, 0, time.UTC).In(location).Zone()
+
+	if summer < winter {
+		//southern hemisphere
+		return time.Duration(summer) * time.Second, true
+	}
+	return time.Duration(winter) * time.Second, summer != winter
+}
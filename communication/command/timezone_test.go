@@ -0,0 +1,119 @@
+package command
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimeZoneId_Location(t *testing.T) {
+	for id := range timeZoneIdMapping {
+		location, err := id.Location()
+		assert.NoError(t, err, id.Name())
+		assert.Equal(t, id.Name(), location.String())
+	}
+
+	_, err := TimeZoneIdNone.Location()
+	assert.ErrorIs(t, err, UnknownTimeZoneError)
+}
+
+func TestTimeZoneIdOf(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	id, ok := TimeZoneIdOf(berlin)
+	assert.True(t, ok)
+	assert.Equal(t, TimeZoneId(37), id)
+
+	//same rules as Europe/Berlin
+	paris, _ := time.LoadLocation("Europe/Paris")
+	id, ok = TimeZoneIdOf(paris)
+	assert.True(t, ok)
+	assert.Equal(t, TimeZoneId(37), id)
+
+	_, ok = TimeZoneIdOf(time.FixedZone("custom", 90))
+	assert.False(t, ok)
+	_, ok = TimeZoneIdOf(nil)
+	assert.False(t, ok)
+}
+
+func TestStatesCommand_CurrentTime(t *testing.T) {
+	states := NewCommand(IdStates, decodeHex("020301E6070A13091E2A3C004A0500020600020103")).AsStatesCommand()
+
+	assert.Equal(t, time.Hour, states.TimezoneOffset())
+	assert.True(t, time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC).Equal(states.CurrentTime()))
+
+	_, offset := states.CurrentTime().Zone()
+	assert.Equal(t, 3600, offset)
+	assert.Equal(t, 10, states.CurrentTime().Hour())
+}
+
+func TestConfigCommand_WithTimeZone(t *testing.T) {
+	payload := testConfigPayload(74)
+	binary.LittleEndian.PutUint16(payload[72:74], uint16(TimeZoneIdNone))
+	config := NewCommand(IdConfig, payload).AsConfigCommand()
+	assert.Equal(t, "nuki", config.Location().String())
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	updated := config.WithTimeZone(paris)
+	assert.Equal(t, TimeZoneId(37), updated.TimeZoneId())
+	assert.Equal(t, time.Hour, updated.TimezoneOffset())
+	assert.Equal(t, DaylightSavingTimeModeEuropean, updated.DSTMode())
+	assert.Equal(t, "Europe/Berlin", updated.Location().String())
+	assert.Equal(t, config.Name(), updated.Name())
+	assert.True(t, updated.CurrentTime().Equal(config.CurrentTimeAsUTC()))
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	updated = config.WithTimeZone(newYork)
+	assert.Equal(t, TimeZoneId(12), updated.TimeZoneId())
+	assert.Equal(t, -5*time.Hour, updated.TimezoneOffset())
+	assert.Equal(t, DaylightSavingTimeModeDisabled, updated.DSTMode())
+
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+	updated = config.WithTimeZone(sydney)
+	assert.Equal(t, 10*time.Hour, updated.TimezoneOffset())
+
+	updated = NewCommand(IdConfig, payload).AsConfigCommand().WithTimeZone(time.FixedZone("custom", -90*60))
+	assert.Equal(t, TimeZoneIdNone, updated.TimeZoneId())
+	assert.Equal(t, -90*time.Minute, updated.TimezoneOffset())
+
+	assert.Nil(t, NewCommand(IdConfig, testConfigPayload(73)).AsConfigCommand().WithTimeZone(paris))
+}
+
+func TestNewSetConfig(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	nonce := make([]byte, 32)
+
+	smartLock := testConfigPayload(74)
+	smartLock[44], smartLock[48], smartLock[64] = 0x01, 0x03, byte(AdvertisingModeSlow)
+	cmd := NewSetConfig(NewCommand(IdConfig, smartLock).AsConfigCommand().WithTimeZone(paris), mustPin("1234"), nonce)
+	assert.True(t, cmd.Is(IdSetConfig))
+	assert.True(t, cmd.CheckCRC())
+	assert.Len(t, cmd.Payload(), 55+32+2)
+	assert.Equal(t, smartLock[4:49], cmd.Payload()[0:45])
+	assert.Equal(t, []byte{0x3C, 0x00, byte(DaylightSavingTimeModeEuropean)}, cmd.Payload()[45:48])
+	assert.Equal(t, byte(AdvertisingModeSlow), cmd.Payload()[52])
+	assert.Equal(t, []byte{37, 0x00}, cmd.Payload()[53:55])
+	assert.Equal(t, []byte{0xD2, 0x04}, cmd.Payload()[87:89])
+
+	opener := testConfigPayload(72)
+	opener[44] = byte(OpenerCapabilitiesOnlyRto)
+	opener[45], opener[46], opener[47] = 0x01, 0x00, 0x01
+	opener[62] = byte(OpenerOperationModeDigitalIntercomTCS)
+	cmd = NewSetConfig(NewCommand(IdConfig, opener).AsConfigCommand().WithTimeZone(paris), mustPin("1234"), nonce)
+	assert.Len(t, cmd.Payload(), 53+32+2)
+	assert.Equal(t, opener[4:44], cmd.Payload()[0:40], "name, latitude and longitude")
+	assert.Equal(t, []byte{0x01, 0x00, 0x01}, cmd.Payload()[40:43], "pairing, button and led flash (without capabilities)")
+	assert.Equal(t, []byte{0x3C, 0x00, byte(DaylightSavingTimeModeEuropean)}, cmd.Payload()[43:46])
+	assert.Equal(t, byte(OpenerOperationModeDigitalIntercomTCS), cmd.Payload()[49])
+	assert.Equal(t, []byte{37, 0x00}, cmd.Payload()[51:53])
+	assert.Equal(t, []byte{0xD2, 0x04}, cmd.Payload()[85:87])
+
+	assert.Nil(t, NewSetConfig(NewCommand(IdConfig, testConfigPayload(73)).AsConfigCommand(), mustPin("1234"), nonce))
+}
+
+func TestNewUpdateTime_UTC(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	cmd := NewUpdateTime(time.Date(2022, 10, 19, 11, 30, 42, 0, berlin), mustPin("1234"), make([]byte, 32))
+
+	assert.Equal(t, []byte{0xE6, 0x07, 0x0A, 0x13, 0x09, 0x1E, 0x2A}, []byte(cmd.Payload()[0:7]))
+}
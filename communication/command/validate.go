@@ -0,0 +1,99 @@
+package command
+
+import (
+	"fmt"
+)
+
+var (
+	// BadLengthError will be returned if the payload of a received command is too short. It is the same error as
+	// communication.ERROR_BAD_LENGTH (which is reported by the device for sent commands).
+	BadLengthError = fmt.Errorf("length of retrieved command payload does not match expected length")
+	// UnexpectedCommandError will be returned if a command has an other id than expected
+	UnexpectedCommandError = fmt.Errorf("unexpected command")
+)
+
+// LengthError describes a command whose payload is shorter than the minimal length of its command id.
+type LengthError struct {
+	Id        Id
+	MinLength int
+	Length    int
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("%s (command 0x%04X: expect at least %d bytes got %d)", BadLengthError.Error(), uint16(e.Id), e.MinLength, e.Length)
+}
+
+// Unwrap returns the BadLengthError.
+func (e *LengthError) Unwrap() error {
+	return BadLengthError
+}
+
+// minPayloadLength contains the minimal payload length of the received commands. Some commands have optional trailing
+// fields (for example the states of newer firmwares): the accessors of these fields handle the missing bytes by
+// themselves.
+var minPayloadLength = map[Id]int{
+	IdPublicKey:       32,
+	IdChallenge:       32,
+	IdAuthorizationID: 84,
+	IdStates:          19,
+	IdStatus:          1,
+	IdErrorReport:     1,
+	IdConfig:          72,
+	IdLogEntry:        48,
+	IdLogEntryCount:   5,
+}
+
+// Validate checks if the command is long enough to contain its id, crc and the payload which is expected for its id.
+// Commands with an unknown id are only checked for id and crc.
+func (c Command) Validate() error {
+	if len(c) < 4 {
+		return &LengthError{Id: c.Id(), MinLength: minPayloadLength[c.Id()], Length: 0}
+	}
+
+	minLength := minPayloadLength[c.Id()]
+	if len(c.Payload()) < minLength {
+		return &LengthError{Id: c.Id(), MinLength: minLength, Length: len(c.Payload())}
+	}
+	return nil
+}
+
+// parse checks if the command has the expected id and a valid length.
+func (c Command) parse(expected Id) error {
+	if !c.Is(expected) {
+		return fmt.Errorf("%w: expect 0x%04X got 0x%04X", UnexpectedCommandError, uint16(expected), uint16(c.Id()))
+	}
+	return c.Validate()
+}
+
+// byteAt returns the byte at the given index of the payload. Returns 0 if the payload is too short.
+func (c Command) byteAt(index int) byte {
+	payload := c.Payload()
+	if index < 0 || index >= len(payload) {
+		return 0
+	}
+	return payload[index]
+}
+
+// bytesAt returns the bytes [from:to] of the payload. Missing bytes (for example of an invalid command) are zeros. So
+// the accessors of the commands do not panic on invalid (or nil) commands.
+func (c Command) bytesAt(from, to int) []byte {
+	payload := c.Payload()
+	if to <= len(payload) {
+		return payload[from:to]
+	}
+
+	result := make([]byte, to-from)
+	if from < len(payload) {
+		copy(result, payload[from:])
+	}
+	return result
+}
+
+// bytesFrom returns the bytes of the payload starting at the given index. Returns nil if the payload is too short.
+func (c Command) bytesFrom(from int) []byte {
+	payload := c.Payload()
+	if from >= len(payload) {
+		return nil
+	}
+	return payload[from:]
+}
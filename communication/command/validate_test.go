@@ -0,0 +1,91 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCommand_Validate(t *testing.T) {
+	assert.NoError(t, NewCommand(IdStates, make([]byte, 19)).Validate())
+	assert.NoError(t, NewCommand(IdRequestData, nil).Validate())
+
+	err := NewCommand(IdStates, make([]byte, 18)).Validate()
+	assert.ErrorIs(t, err, BadLengthError)
+	assert.Equal(t, &LengthError{Id: IdStates, MinLength: 19, Length: 18}, err)
+
+	assert.ErrorIs(t, Command{0x0C, 0x00, 0x00}.Validate(), BadLengthError)
+	assert.ErrorIs(t, Command(nil).Validate(), BadLengthError)
+}
+
+func TestCommand_ParseStatesCommand(t *testing.T) {
+	states, err := NewCommand(IdStates, make([]byte, 19)).ParseStatesCommand()
+	assert.NoError(t, err)
+	assert.NotNil(t, states)
+
+	_, err = NewCommand(IdStatus, []byte{0x00}).ParseStatesCommand()
+	assert.ErrorIs(t, err, UnexpectedCommandError)
+
+	_, err = NewCommand(IdStates, make([]byte, 3)).ParseStatesCommand()
+	assert.ErrorIs(t, err, BadLengthError)
+}
+
+func TestAsCommand_Truncated(t *testing.T) {
+	assert.Nil(t, NewCommand(IdStates, make([]byte, 3)).AsStatesCommand())
+	assert.Nil(t, NewCommand(IdConfig, make([]byte, 36)).AsConfigCommand())
+	assert.Nil(t, NewCommand(IdLogEntry, make([]byte, 20)).AsLogEntryCommand())
+	assert.Nil(t, NewCommand(IdAuthorizationID, make([]byte, 52)).AsAuthorizationIdCommand())
+	assert.Nil(t, NewCommand(IdStatus, nil).AsStatusCommand())
+	assert.Nil(t, NewCommand(IdChallenge, make([]byte, 8)).AsChallengeCommand())
+	assert.Nil(t, NewCommand(IdPublicKey, make([]byte, 8)).AsPublicKeyCommand())
+	assert.Nil(t, NewCommand(IdLogEntryCount, make([]byte, 3)).AsLogEntriesCountCommand())
+}
+
+func TestStatesCommand_ShortSmartLockStates(t *testing.T) {
+	states := NewCommand(IdStates, make([]byte, 19)).AsStatesCommand().AsSmartLockStates()
+
+	assert.NotPanics(t, func() {
+		assert.False(t, states.NightModeActive())
+		supported, _ := states.AccessoryBatteryState()
+		assert.False(t, supported)
+		_ = states.String()
+	})
+}
+
+func TestLogEntryCommand_TruncatedType(t *testing.T) {
+	payload := make([]byte, 49)
+	payload[47] = byte(LoggingTypeKeypadAction)
+	logEntry := NewCommand(IdLogEntry, payload).AsLogEntryCommand()
+
+	assert.Nil(t, logEntry.AsKeypadAction())
+	assert.NotPanics(t, func() {
+		assert.Contains(t, logEntry.String(), "Truncated")
+	})
+}
+
+func TestAsCommand_NilAccessors(t *testing.T) {
+	assert.NotPanics(t, func() {
+		status := NewCommand(IdStatus, nil).AsStatusCommand()
+		assert.False(t, status.IsComplete())
+		_ = status.String()
+
+		states := NewCommand(IdStates, make([]byte, 3)).AsStatesCommand()
+		assert.Equal(t, LockState(0), states.LockState())
+		_ = states.String()
+		_ = states.AsSmartLockStates().String()
+		_ = states.AsOpenerStates().String()
+
+		config := NewCommand(IdConfig, make([]byte, 36)).AsConfigCommand()
+		assert.Equal(t, uint32(0), config.NukiId())
+		_ = config.String()
+		_ = config.AsSmartLockConfig().String()
+		_ = config.AsOpenerConfig().String()
+
+		logEntry := NewCommand(IdLogEntry, make([]byte, 20)).AsLogEntryCommand()
+		assert.Equal(t, uint32(0), logEntry.Index())
+		_ = logEntry.String()
+
+		_ = NewCommand(IdLogEntryCount, nil).AsLogEntriesCountCommand().String()
+		_ = NewCommand(IdAuthorizationID, nil).AsAuthorizationIdCommand().String()
+		_ = ErrorReportCommand(nil).String()
+	})
+}
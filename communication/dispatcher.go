@@ -0,0 +1,189 @@
+package communication
+
+import (
+	"context"
+	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"sync"
+	"time"
+)
+
+// subscriptionBufferSize is the count of commands which can be buffered for each subscriber before
+// further commands will be dropped for it.
+const subscriptionBufferSize = 32
+
+// maxPendingSize is the count of received commands which will be buffered for the waiting functions. If nobody
+// waits (for example for states which are pushed by the device), the oldest commands will be dropped.
+const maxPendingSize = 64
+
+type received struct {
+	cmd command.Command
+	err error
+}
+
+// dispatcher decouples the (ble) receiving of commands from the consumption of them. The receiving side
+// will never block: each received command is buffered for the waiting functions and published to all subscribers.
+type dispatcher struct {
+	mu          sync.Mutex
+	pending     []received
+	signal      chan struct{}
+	subscribers map[chan command.Command]struct{}
+	closed      bool
+	done        chan struct{}
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		signal:      make(chan struct{}, 1),
+		subscribers: map[chan command.Command]struct{}{},
+		done:        make(chan struct{}),
+	}
+}
+
+func (d *dispatcher) deliver(cmd command.Command, logPrefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	for sub := range d.subscribers {
+		select {
+		case sub <- cmd:
+		default:
+			if logger.Debug != nil {
+				logger.Debug.Printf("%s Subscriber is too slow. Drop command: 0x%04x", logPrefix, cmd.Id())
+			}
+		}
+	}
+
+	d.push(received{cmd: cmd}, logPrefix)
+}
+
+func (d *dispatcher) deliverError(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	d.push(received{err: err}, "")
+}
+
+func (d *dispatcher) push(r received, logPrefix string) {
+	if len(d.pending) >= maxPendingSize {
+		if d.pending[0].cmd != nil && logger.Debug != nil {
+			logger.Debug.Printf("%s Too many unconsumed commands. Drop command: 0x%04x", logPrefix, d.pending[0].cmd.Id())
+		}
+		d.pending[0] = received{}
+		d.pending = d.pending[1:]
+	}
+	d.pending = append(d.pending, r)
+
+	select {
+	case d.signal <- struct{}{}:
+	default:
+	}
+}
+
+// discardPending will drop all received but not consumed commands. These commands can not be an answer of a request
+// which will be sent afterwards.
+func (d *dispatcher) discardPending(logPrefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, r := range d.pending {
+		if r.cmd != nil && logger.Debug != nil {
+			logger.Debug.Printf("%s Discard unconsumed command: 0x%04x", logPrefix, r.cmd.Id())
+		}
+	}
+	d.pending = nil
+}
+
+func (d *dispatcher) pop() (received, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.pending) == 0 {
+		return received{}, false
+	}
+
+	r := d.pending[0]
+	d.pending[0] = received{}
+	d.pending = d.pending[1:]
+
+	return r, true
+}
+
+func (d *dispatcher) next(ctx context.Context, timeout time.Duration) (command.Command, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		if r, ok := d.pop(); ok {
+			return r.cmd, r.err
+		}
+
+		select {
+		case <-d.signal:
+		case <-d.done:
+			return nil, ClosedErr
+		case <-timer.C:
+			return nil, TimeoutErr
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (d *dispatcher) subscribe(ctx context.Context) <-chan command.Command {
+	sub := make(chan command.Command, subscriptionBufferSize)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		close(sub)
+		return sub
+	}
+	d.subscribers[sub] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.unsubscribe(sub)
+		case <-d.done:
+		}
+	}()
+
+	return sub
+}
+
+func (d *dispatcher) unsubscribe(sub chan command.Command) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subscribers[sub]; ok {
+		delete(d.subscribers, sub)
+		close(sub)
+	}
+}
+
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+
+	d.closed = true
+	close(d.done)
+	for sub := range d.subscribers {
+		delete(d.subscribers, sub)
+		close(sub)
+	}
+	d.pending = nil
+}
@@ -0,0 +1,90 @@
+package communication
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func TestDispatcher_DeliverWithoutWaiter(t *testing.T) {
+	toTest := newDispatcher()
+
+	done := make(chan struct{})
+	go func() {
+		toTest.deliver(command.NewRequest(command.IdStates), "")
+		toTest.deliver(command.NewRequest(command.IdStatus), "")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("delivering blocks without any waiter")
+	}
+
+	cmd, err := toTest.next(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, command.NewRequest(command.IdStates), cmd)
+}
+
+func TestDispatcher_PendingLimit(t *testing.T) {
+	toTest := newDispatcher()
+
+	for i := 0; i < maxPendingSize+10; i++ {
+		toTest.deliver(command.NewCommand(command.IdStates, []byte{byte(i)}), "")
+	}
+	assert.Len(t, toTest.pending, maxPendingSize)
+
+	cmd, err := toTest.next(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{10}, cmd.Payload(), "the oldest commands should be dropped")
+}
+
+func TestDispatcher_DiscardPending(t *testing.T) {
+	toTest := newDispatcher()
+
+	toTest.deliver(command.NewRequest(command.IdStates), "")
+	toTest.discardPending("")
+
+	_, err := toTest.next(context.Background(), 10*time.Millisecond)
+	assert.Equal(t, TimeoutErr, err)
+}
+
+func TestDispatcher_Subscribe(t *testing.T) {
+	toTest := newDispatcher()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := toTest.subscribe(ctx)
+
+	toTest.deliver(command.NewRequest(command.IdStates), "")
+	assert.Equal(t, command.NewRequest(command.IdStates), <-sub)
+
+	//the waiting functions will receive the command too
+	cmd, err := toTest.next(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, command.NewRequest(command.IdStates), cmd)
+
+	cancel()
+	select {
+	case _, ok := <-sub:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not closed after context is done")
+	}
+}
+
+func TestWaitForResponse_MalformedErrorReport(t *testing.T) {
+	disp := newDispatcher()
+	disp.deliver(command.NewCommand(command.IdErrorReport, nil), "")
+	disp.deliver(command.NewCommand(command.IdErrorReport, nil), "")
+
+	cmd, err := waitForResponse(context.Background(), DeviceTypeSmartLock, time.Second, disp)
+	assert.Nil(t, cmd)
+	assert.ErrorIs(t, err, ERROR_UNKNOWN)
+
+	cmd, err = waitForSpecificResponse(context.Background(), DeviceTypeSmartLock, command.IdStatus, time.Second, disp, "")
+	assert.Nil(t, cmd)
+	assert.ErrorIs(t, err, ERROR_UNKNOWN)
+}
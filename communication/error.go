@@ -1,13 +1,15 @@
 package communication
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/tarent/go-nuki/communication/command"
 )
 
 var (
 	ERROR_BAD_CRC    = errors.New("CRC of received command is invalid")
-	ERROR_BAD_LENGTH = errors.New("length of retrieved command payload does not match expected length")
+	ERROR_BAD_LENGTH = command.BadLengthError
 	ERROR_UNKNOWN    = errors.New("unknown error")
 
 	P_ERROR_NOT_PAIRING       = errors.New("public key is being requested via request data command, but the device is not in pairing mode")
@@ -50,15 +52,112 @@ var (
 	K_ERROR_OPERATING_MODE_UNKNOWN = errors.New("operating mode is not in the valid range of the firmware")
 )
 
+// ErrorCategory is the origin of an error which was reported by the device.
+type ErrorCategory uint8
+
+const (
+	ErrorCategoryGeneral   = ErrorCategory(0x00)
+	ErrorCategoryPairing   = ErrorCategory(0x01)
+	ErrorCategoryKeyturner = ErrorCategory(0x02)
+	ErrorCategoryOpener    = ErrorCategory(0x03)
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorCategoryGeneral:
+		return "general"
+	case ErrorCategoryPairing:
+		return "pairing"
+	case ErrorCategoryKeyturner:
+		return "keyturner"
+	case ErrorCategoryOpener:
+		return "opener"
+	}
+	return fmt.Sprintf("unknown (0x%02X)", uint8(c))
+}
+
+// NukiError is an error which was reported by the device (see command.IdErrorReport). It wraps one of the
+// error values above, so errors.Is can be used to check for a specific error:
+//
+//	errors.Is(err, communication.K_ERROR_BAD_PIN)
+type NukiError struct {
+	// Code is the raw error code of the device.
+	Code uint8
+	// Category is the origin of the error.
+	Category ErrorCategory
+	// CommandId is the id of the command which caused the error.
+	CommandId command.Id
+	// DeviceType is the type of the device which reported the error.
+	DeviceType DeviceType
+
+	cause error
+}
+
+func (e *NukiError) Error() string {
+	return fmt.Sprintf("%s (code 0x%02X, command 0x%04X)", e.cause.Error(), e.Code, uint16(e.CommandId))
+}
+
+// Unwrap returns the error value which belongs to the code (e.g. K_ERROR_BAD_PIN).
+func (e *NukiError) Unwrap() error {
+	return e.cause
+}
+
+// Retryable returns true if the same request may succeed if it is sent again. For example a busy device or an
+// outdated nonce (the request must be built with a new challenge).
+func (e *NukiError) Retryable() bool {
+	switch e.cause {
+	case ERROR_BAD_CRC, ERROR_BAD_LENGTH, K_ERROR_BAD_NONCE, K_ERROR_BUSY:
+		return true
+	}
+	return false
+}
+
+// Error returns the error (a *NukiError) which is reported by the given command. Returns nil if the given command is
+// not an error report. A malformed error report (without error code) is reported as unknown error (code 0xFF).
 func Error(c command.Command, deviceType DeviceType) error {
 	if c.Id() != command.IdErrorReport {
 		return nil
 	}
-	if len(c) < 3 {
-		return nil
+	//2 byte id + 1 byte code + 2 byte crc
+	if len(c) < 5 {
+		return &NukiError{
+			Code:       0xFF,
+			Category:   ErrorCategoryGeneral,
+			DeviceType: deviceType,
+			cause:      ERROR_UNKNOWN,
+		}
+	}
+
+	payload := c.Payload()
+	code := payload[0]
+	result := &NukiError{
+		Code:       code,
+		Category:   errorCategory(code, deviceType),
+		DeviceType: deviceType,
+		cause:      errorCause(code, deviceType),
+	}
+	if len(payload) >= 3 {
+		result.CommandId = command.Id(binary.LittleEndian.Uint16(payload[1:3]))
 	}
 
-	switch c[2] {
+	return result
+}
+
+func errorCategory(code uint8, deviceType DeviceType) ErrorCategory {
+	switch {
+	case code >= 0x10 && code < 0x20:
+		return ErrorCategoryPairing
+	case code >= 0x20 && code < 0xFD:
+		if deviceType == DeviceTypeOpener {
+			return ErrorCategoryOpener
+		}
+		return ErrorCategoryKeyturner
+	}
+	return ErrorCategoryGeneral
+}
+
+func errorCause(code uint8, deviceType DeviceType) error {
+	switch code {
 	case 0xFD:
 		return ERROR_BAD_CRC
 	case 0xFE:
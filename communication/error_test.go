@@ -0,0 +1,77 @@
+package communication
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	err := Error(command.NewCommand(command.IdErrorReport, []byte{0x21, 0x0D, 0x00}), DeviceTypeSmartLock)
+
+	assert.ErrorIs(t, err, K_ERROR_BAD_PIN)
+
+	nukiErr := &NukiError{}
+	assert.True(t, errors.As(err, &nukiErr))
+	assert.Equal(t, uint8(0x21), nukiErr.Code)
+	assert.Equal(t, ErrorCategoryKeyturner, nukiErr.Category)
+	assert.Equal(t, command.IdLockAction, nukiErr.CommandId)
+	assert.Equal(t, DeviceTypeSmartLock, nukiErr.DeviceType)
+	assert.False(t, nukiErr.Retryable())
+	assert.Equal(t, "the provided pin does not match the stored one (code 0x21, command 0x000D)", err.Error())
+}
+
+func TestError_Categories(t *testing.T) {
+	tests := []struct {
+		code       uint8
+		deviceType DeviceType
+		category   ErrorCategory
+		cause      error
+	}{
+		{0xFD, DeviceTypeSmartLock, ErrorCategoryGeneral, ERROR_BAD_CRC},
+		{0x11, DeviceTypeSmartLock, ErrorCategoryPairing, P_ERROR_BAD_AUTHENTICATOR},
+		{0x47, DeviceTypeSmartLock, ErrorCategoryKeyturner, K_ERROR_SL_NOT_CALIBRATED},
+		{0x47, DeviceTypeOpener, ErrorCategoryOpener, K_ERROR_OPENER_NOT_CALIBRATED},
+		{0x99, DeviceTypeSmartLock, ErrorCategoryKeyturner, ERROR_UNKNOWN},
+	}
+	for _, tt := range tests {
+		err := Error(command.NewCommand(command.IdErrorReport, []byte{tt.code, 0x01, 0x00}), tt.deviceType)
+
+		assert.ErrorIs(t, err, tt.cause)
+		assert.Equal(t, tt.category, err.(*NukiError).Category, "code 0x%02X", tt.code)
+	}
+}
+
+func TestError_Retryable(t *testing.T) {
+	busy := Error(command.NewCommand(command.IdErrorReport, []byte{0x45, 0x0D, 0x00}), DeviceTypeSmartLock)
+	badNonce := Error(command.NewCommand(command.IdErrorReport, []byte{0x22, 0x0D, 0x00}), DeviceTypeSmartLock)
+	motorBlocked := Error(command.NewCommand(command.IdErrorReport, []byte{0x42, 0x0D, 0x00}), DeviceTypeSmartLock)
+
+	assert.True(t, busy.(*NukiError).Retryable())
+	assert.True(t, badNonce.(*NukiError).Retryable())
+	assert.False(t, motorBlocked.(*NukiError).Retryable())
+}
+
+func TestError_NoErrorReport(t *testing.T) {
+	assert.Nil(t, Error(command.NewRequest(command.IdStates), DeviceTypeSmartLock))
+}
+
+func TestError_WithoutCommandId(t *testing.T) {
+	//the crc must not be read as command id
+	err := Error(command.NewCommand(command.IdErrorReport, []byte{0x21}), DeviceTypeSmartLock)
+
+	assert.ErrorIs(t, err, K_ERROR_BAD_PIN)
+	assert.Equal(t, command.Id(0), err.(*NukiError).CommandId)
+}
+
+func TestError_Malformed(t *testing.T) {
+	//id and crc only
+	err := Error(command.NewCommand(command.IdErrorReport, nil), DeviceTypeSmartLock)
+
+	assert.ErrorIs(t, err, ERROR_UNKNOWN)
+	assert.Equal(t, ErrorCategoryGeneral, err.(*NukiError).Category)
+
+	err = Error(command.Command{0x12, 0x00}, DeviceTypeSmartLock)
+	assert.ErrorIs(t, err, ERROR_UNKNOWN)
+}
@@ -0,0 +1,115 @@
+package communication
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+)
+
+// traceEncryptedStates is an encrypted keyturner states frame of the nuki api documentation.
+const traceEncryptedStates = "90B0757CFED0243017EAF5E089F8583B9839D61B050924D2020000002700B13938B67121B6D528E7DE206B0D7C5A94587A471B33EBFB012CED8F1261135566ED756E3910B5"
+
+func fuzzKeys() (privKey, nukiPubKey []byte) {
+	privKey, _ = hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	nukiPubKey, _ = hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	return
+}
+
+// fragments splits the given data into fragments of the given size like they are received via ble.
+func fragments(data []byte, size uint8) [][]byte {
+	if size == 0 || size > mtu {
+		size = mtu
+	}
+
+	var result [][]byte
+	for len(data) > int(size) {
+		result = append(result, data[:size])
+		data = data[size:]
+	}
+	return append(result, data)
+}
+
+// checkDelivered checks that all delivered commands are valid.
+func checkDelivered(t *testing.T, disp *dispatcher) {
+	for {
+		r, ok := disp.pop()
+		if !ok {
+			return
+		}
+		if r.err == nil && !r.cmd.CheckCRC() {
+			t.Errorf("delivered command with invalid crc: %s", r.cmd.String())
+		}
+	}
+}
+
+func FuzzGdioCommunicator_receive(f *testing.F) {
+	f.Add([]byte(command.NewCommand(command.IdStatus, []byte{0x00})), uint8(mtu))
+	f.Add([]byte(command.NewCommand(command.IdChallenge, make([]byte, 32))), uint8(mtu))
+	f.Add([]byte(command.NewCommand(command.IdAuthorizationID, make([]byte, 84))), uint8(7))
+	f.Add([]byte{0x0C}, uint8(mtu))
+	f.Add([]byte{}, uint8(mtu))
+
+	f.Fuzz(func(t *testing.T, data []byte, size uint8) {
+		toTest := &gdioCommunicator{disp: newDispatcher()}
+
+		for _, fragment := range fragments(data, size) {
+			toTest.receive(fragment)
+		}
+		checkDelivered(t, toTest.disp)
+	})
+}
+
+func FuzzUdioCommunicator_receive(f *testing.F) {
+	privKey, nukiPubKey := fuzzKeys()
+	frame, _ := hex.DecodeString(traceEncryptedStates)
+	status := command.EncryptCommand(2, privKey, nukiPubKey, command.NewCommand(command.IdStatus, []byte{0x00}))
+
+	f.Add(frame, uint8(mtu))
+	f.Add(append(append([]byte{}, frame...), status...), uint8(mtu))
+	f.Add(append(append([]byte{}, frame[:40]...), status...), uint8(mtu))
+	f.Add([]byte(status), uint8(3))
+	f.Add(frame[:29], uint8(mtu))
+
+	f.Fuzz(func(t *testing.T, data []byte, size uint8) {
+		toTest := &udioCommunicator{
+			disp:      newDispatcher(),
+			nonces:    newNonceTracker(nonceWindowSize),
+			authId:    2,
+			sharedKey: command.NewSharedKey(privKey, nukiPubKey),
+		}
+
+		for _, fragment := range fragments(data, size) {
+			toTest.receive(fragment)
+		}
+		checkDelivered(t, toTest.disp)
+	})
+}
+
+func TestUdioCommunicator_ReceiveOversizedCommand(t *testing.T) {
+	privKey, nukiPubKey := fuzzKeys()
+	toTest := &udioCommunicator{
+		disp:      newDispatcher(),
+		nonces:    newNonceTracker(nonceWindowSize),
+		authId:    2,
+		sharedKey: command.NewSharedKey(privKey, nukiPubKey),
+	}
+
+	//the header announces a message length of 0x0001, but a whole fragment follows
+	toTest.receive(make([]byte, 20))
+	toTest.receive(append([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, make([]byte, 20)...))
+
+	r, ok := toTest.disp.pop()
+	assert.True(t, ok)
+	assert.ErrorIs(t, r.err, ERROR_BAD_LENGTH)
+
+	//the following commands can be received again
+	for _, fragment := range fragments(command.EncryptCommand(2, privKey, nukiPubKey, command.NewCommand(command.IdStatus, []byte{0x00})), mtu) {
+		toTest.receive(fragment)
+	}
+
+	r, ok = toTest.disp.pop()
+	assert.True(t, ok)
+	assert.NoError(t, r.err)
+	assert.True(t, r.cmd.Is(command.IdStatus))
+}
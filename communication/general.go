@@ -14,6 +14,9 @@ const mtu = 20
 // TimeoutErr will be occurred if the used timeout exceeded
 var TimeoutErr = fmt.Errorf("timeout exceeded")
 
+// ClosedErr will be occurred if the communicator was closed while waiting for a response
+var ClosedErr = fmt.Errorf("communicator is closed")
+
 type Communicator interface {
 	// Send will send the given command to the connected nuki device
 	Send(cmd command.Command) error
@@ -26,6 +29,11 @@ type Communicator interface {
 	// the response was erroneous or the response command is not the expected type.
 	WaitForSpecificResponse(ctx context.Context, expectedType command.Id, timeout time.Duration) (command.Command, error)
 
+	// Subscribe will return a channel which receives each command sent by the device - regardless of whether someone
+	// is waiting for a response or not. The channel will be closed if the given context is done or the communicator
+	// is closed. Slow subscribers will miss commands instead of blocking the communication.
+	Subscribe(ctx context.Context) <-chan command.Command
+
 	// GetDeviceType will return the discovered device type.
 	GetDeviceType() DeviceType
 
@@ -34,48 +42,42 @@ type Communicator interface {
 	Close() error
 }
 
-func waitForResponse(ctx context.Context, deviceType DeviceType, timeout time.Duration, cmdChan chan command.Command, errChan chan error) (command.Command, error) {
-	select {
-	case cmd := <-cmdChan:
-		if cmd.Is(command.IdErrorReport) {
-			return nil, Error(cmd, deviceType)
-		}
-
-		return cmd, nil
-	case err := <-errChan:
+func waitForResponse(ctx context.Context, deviceType DeviceType, timeout time.Duration, disp *dispatcher) (command.Command, error) {
+	cmd, err := disp.next(ctx, timeout)
+	if err != nil {
 		return nil, err
-	case <-time.After(timeout):
-		return nil, TimeoutErr
-	case <-ctx.Done():
-		return nil, ctx.Err()
 	}
+
+	if cmd.Is(command.IdErrorReport) {
+		return nil, Error(cmd, deviceType)
+	}
+
+	return cmd, nil
 }
 
-func waitForSpecificResponse(ctx context.Context, deviceType DeviceType, expectedType command.Id, timeout time.Duration, cmdChan chan command.Command, errChan chan error, logPrefix string) (command.Command, error) {
+func waitForSpecificResponse(ctx context.Context, deviceType DeviceType, expectedType command.Id, timeout time.Duration, disp *dispatcher, logPrefix string) (command.Command, error) {
+	deadline := time.Now().Add(timeout)
+
 	for {
-		select {
-		case cmd := <-cmdChan:
-			if expectedType != command.IdErrorReport && cmd.Is(command.IdErrorReport) {
-				return nil, Error(cmd, deviceType)
-			}
+		cmd, err := disp.next(ctx, time.Until(deadline))
+		if err != nil {
+			return nil, err
+		}
 
-			if !cmd.Is(expectedType) {
-				if logger.Debug != nil {
-					logger.Debug.Printf("%s Unexpected response type: 0x%04x. Skip this command because of waiting for type: 0x%04x",
-						logPrefix, cmd.Id(), expectedType,
-					)
-				}
+		if expectedType != command.IdErrorReport && cmd.Is(command.IdErrorReport) {
+			return nil, Error(cmd, deviceType)
+		}
 
-				continue
+		if !cmd.Is(expectedType) {
+			if logger.Debug != nil {
+				logger.Debug.Printf("%s Unexpected response type: 0x%04x. Skip this command because of waiting for type: 0x%04x",
+					logPrefix, cmd.Id(), expectedType,
+				)
 			}
 
-			return cmd, nil
-		case err := <-errChan:
-			return nil, err
-		case <-time.After(timeout):
-			return nil, TimeoutErr
-		case <-ctx.Done():
-			return nil, ctx.Err()
+			continue
 		}
+
+		return cmd, nil
 	}
 }
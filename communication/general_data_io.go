@@ -10,8 +10,7 @@ import (
 )
 
 type gdioCommunicator struct {
-	commandChan chan command.Command
-	errorChan   chan error
+	disp *dispatcher
 
 	curCommand command.Command
 
@@ -23,9 +22,8 @@ type gdioCommunicator struct {
 // NewGeneralDataIOCommunicator establish a new communicator to the "general data io" characteristic to the connected nuki device.
 func NewGeneralDataIOCommunicator(client ble.Client) (Communicator, error) {
 	com := &gdioCommunicator{
-		commandChan: make(chan command.Command),
-		errorChan:   make(chan error),
-		deviceType:  DeviceTypeUnknown,
+		disp:       newDispatcher(),
+		deviceType: DeviceTypeUnknown,
 	}
 
 	var err error
@@ -46,6 +44,8 @@ func (g *gdioCommunicator) Send(cmd command.Command) error {
 	if logger.Info != nil {
 		logger.Info.Printf("[GDIO][OUT] %s", cmd.String())
 	}
+	g.disp.discardPending("[GDIO][IN]")
+
 	err := g.client.WriteCharacteristic(g.gdioChar, cmd, false)
 	if err != nil {
 		return fmt.Errorf("error while send command: %w", err)
@@ -55,11 +55,15 @@ func (g *gdioCommunicator) Send(cmd command.Command) error {
 }
 
 func (g *gdioCommunicator) WaitForResponse(ctx context.Context, timeout time.Duration) (command.Command, error) {
-	return waitForResponse(ctx, g.deviceType, timeout, g.commandChan, g.errorChan)
+	return waitForResponse(ctx, g.deviceType, timeout, g.disp)
 }
 
 func (g *gdioCommunicator) WaitForSpecificResponse(ctx context.Context, expectedType command.Id, timeout time.Duration) (command.Command, error) {
-	return waitForSpecificResponse(ctx, g.deviceType, expectedType, timeout, g.commandChan, g.errorChan, "[GDIO][IN]")
+	return waitForSpecificResponse(ctx, g.deviceType, expectedType, timeout, g.disp, "[GDIO][IN]")
+}
+
+func (g *gdioCommunicator) Subscribe(ctx context.Context) <-chan command.Command {
+	return g.disp.subscribe(ctx)
 }
 
 func (g *gdioCommunicator) receive(payload []byte) {
@@ -79,16 +83,20 @@ func (g *gdioCommunicator) receive(payload []byte) {
 		logger.Info.Printf("[GDIO][IN][COMPLETE] %s", g.curCommand.String())
 	}
 
-	if !g.curCommand.CheckCRC() {
-		g.errorChan <- ERROR_BAD_CRC
+	complete := g.curCommand
+	g.curCommand = []byte{} //clear command
+
+	if !complete.CheckCRC() {
+		g.disp.deliverError(ERROR_BAD_CRC)
 		return
 	}
 
-	g.commandChan <- g.curCommand
-	g.curCommand = []byte{} //clear command
+	g.disp.deliver(complete, "[GDIO][IN]")
 }
 
 func (g *gdioCommunicator) Close() error {
+	g.disp.close()
+
 	if err := g.client.Unsubscribe(g.gdioChar, true); err != nil {
 		return fmt.Errorf("unable to unsubscribe GDIO: %w", err)
 	}
@@ -0,0 +1,49 @@
+package communication
+
+import "fmt"
+
+// ReplayedMessageError will be returned if a received message uses a nonce which was already seen in this session.
+// This happens if a captured (encrypted) message is sent again by someone else.
+var ReplayedMessageError = fmt.Errorf("received message was replayed: nonce already seen")
+
+// nonceWindowSize is the count of the most recent nonces which will be remembered
+const nonceWindowSize = 1024
+
+const nonceSize = 24
+
+// nonceTracker remembers the most recently seen nonces (received and sent ones).
+type nonceTracker struct {
+	seen  map[[nonceSize]byte]struct{}
+	order [][nonceSize]byte
+	next  int
+}
+
+func newNonceTracker(size int) *nonceTracker {
+	return &nonceTracker{
+		seen:  make(map[[nonceSize]byte]struct{}, size),
+		order: make([][nonceSize]byte, 0, size),
+	}
+}
+
+// track will remember the given nonce. Returns false if the nonce was already seen before.
+func (n *nonceTracker) track(nonce []byte) bool {
+	var key [nonceSize]byte
+	copy(key[:], nonce)
+
+	if _, seen := n.seen[key]; seen {
+		return false
+	}
+	n.seen[key] = struct{}{}
+
+	if len(n.order) < cap(n.order) {
+		n.order = append(n.order, key)
+		return true
+	}
+
+	//forget the oldest one
+	delete(n.seen, n.order[n.next])
+	n.order[n.next] = key
+	n.next = (n.next + 1) % len(n.order)
+
+	return true
+}
@@ -0,0 +1,45 @@
+package communication
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func TestNonceTracker(t *testing.T) {
+	toTest := newNonceTracker(2)
+
+	assert.True(t, toTest.track([]byte{0x01}))
+	assert.True(t, toTest.track([]byte{0x02}))
+	assert.False(t, toTest.track([]byte{0x01}))
+
+	//the oldest one will be forgotten
+	assert.True(t, toTest.track([]byte{0x03}))
+	assert.True(t, toTest.track([]byte{0x01}))
+	assert.False(t, toTest.track([]byte{0x03}))
+}
+
+func TestUdioCommunicator_RejectReplayedMessage(t *testing.T) {
+	privKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	nukiPubKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+
+	toTest := &udioCommunicator{
+		disp:      newDispatcher(),
+		nonces:    newNonceTracker(nonceWindowSize),
+		authId:    2,
+		sharedKey: command.NewSharedKey(privKey, nukiPubKey),
+	}
+	frame := command.EncryptCommand(2, privKey, nukiPubKey, command.NewCommand(command.IdStatus, []byte{0x00}))
+
+	toTest.receive(frame)
+	cmd, err := toTest.disp.next(context.Background(), time.Second)
+	assert.NoError(t, err)
+	assert.True(t, cmd.Is(command.IdStatus))
+
+	toTest.receive(frame)
+	_, err = toTest.disp.next(context.Background(), time.Second)
+	assert.ErrorIs(t, err, ReplayedMessageError)
+}
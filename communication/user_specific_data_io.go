@@ -5,8 +5,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/go-ble/ble"
+	"github.com/kevinburke/nacl"
 	"github.com/tarent/go-nuki/communication/command"
 	"github.com/tarent/go-nuki/logger"
+	"sync"
 	"time"
 )
 
@@ -14,13 +16,13 @@ var DecryptionError = fmt.Errorf("unable to decrypt message")
 var UnexpectedAuthId = fmt.Errorf("unexpected authorization id")
 
 type udioCommunicator struct {
-	commandChan chan command.Command
-	errorChan   chan error
+	disp *dispatcher
 
 	curEncryptedCommand command.Command
+	nonces              *nonceTracker
+	noncesMu            sync.Mutex
 	authId              uint32
-	privKey             []byte
-	nukiPubKey          []byte
+	sharedKey           nacl.Key
 
 	client     ble.Client
 	udioChar   *ble.Characteristic
@@ -29,13 +31,18 @@ type udioCommunicator struct {
 
 // NewUserSpecificDataIOCommunicator establish a new communicator to the "user-specific data io" characteristic to the connected nuki device.
 func NewUserSpecificDataIOCommunicator(client ble.Client, authId uint32, userPrivateKey, nukiPublicKey []byte) (Communicator, error) {
+	return NewUserSpecificDataIOCommunicatorWithSharedKey(client, authId, command.NewSharedKey(userPrivateKey, nukiPublicKey))
+}
+
+// NewUserSpecificDataIOCommunicatorWithSharedKey establish a new communicator to the "user-specific data io" characteristic
+// to the connected nuki device. The given shared key must be precomputed by command.NewSharedKey.
+func NewUserSpecificDataIOCommunicatorWithSharedKey(client ble.Client, authId uint32, sharedKey nacl.Key) (Communicator, error) {
 	com := &udioCommunicator{
-		commandChan: make(chan command.Command),
-		errorChan:   make(chan error),
-		deviceType:  DeviceTypeUnknown,
-		authId:      authId,
-		privKey:     userPrivateKey,
-		nukiPubKey:  nukiPublicKey,
+		disp:       newDispatcher(),
+		nonces:     newNonceTracker(nonceWindowSize),
+		deviceType: DeviceTypeUnknown,
+		authId:     authId,
+		sharedKey:  sharedKey,
 	}
 
 	var err error
@@ -56,8 +63,12 @@ func (u *udioCommunicator) Send(cmd command.Command) error {
 	if logger.Info != nil {
 		logger.Info.Printf("[UDIO][OUT][PLAIN] %s", cmd.String())
 	}
+	u.disp.discardPending("[UDIO][IN]")
 
-	encryptedCmd := command.EncryptCommand(u.authId, u.privKey, u.nukiPubKey, cmd)
+	encryptedCmd := command.EncryptCommandWithSharedKey(u.authId, u.sharedKey, cmd)
+
+	//an own message which is reflected back must not be accepted as response
+	u.trackNonce(encryptedCmd[:nonceSize])
 
 	if logger.Debug != nil {
 		logger.Debug.Printf("[UDIO][OUT][ENCRYPTED] %s", hex.EncodeToString(encryptedCmd))
@@ -72,11 +83,15 @@ func (u *udioCommunicator) Send(cmd command.Command) error {
 }
 
 func (u *udioCommunicator) WaitForResponse(ctx context.Context, timeout time.Duration) (command.Command, error) {
-	return waitForResponse(ctx, u.deviceType, timeout, u.commandChan, u.errorChan)
+	return waitForResponse(ctx, u.deviceType, timeout, u.disp)
 }
 
 func (u *udioCommunicator) WaitForSpecificResponse(ctx context.Context, expectedType command.Id, timeout time.Duration) (command.Command, error) {
-	return waitForSpecificResponse(ctx, u.deviceType, expectedType, timeout, u.commandChan, u.errorChan, "[UDIO][IN]")
+	return waitForSpecificResponse(ctx, u.deviceType, expectedType, timeout, u.disp, "[UDIO][IN]")
+}
+
+func (u *udioCommunicator) Subscribe(ctx context.Context) <-chan command.Command {
+	return u.disp.subscribe(ctx)
 }
 
 func (u *udioCommunicator) receive(payload []byte) {
@@ -87,16 +102,24 @@ func (u *udioCommunicator) receive(payload []byte) {
 	u.curEncryptedCommand = append(u.curEncryptedCommand, payload...)
 
 	if !command.IsCommandComplete(u.curEncryptedCommand) {
+		if expectedLength, ok := command.EncryptedCommandLength(u.curEncryptedCommand); ok && len(u.curEncryptedCommand) > expectedLength {
+			//a fragment was lost or does not belong to this command: the command can never be completed
+			u.curEncryptedCommand = []byte{}
+			u.disp.deliverError(ERROR_BAD_LENGTH)
+		}
+
 		//we expect more data
 		return
 	}
 
 	//command seems to be completed
+	complete := u.curEncryptedCommand
+	u.curEncryptedCommand = []byte{} //clear command
 
-	authId, decryptedCommand := command.DecryptCommand(u.curEncryptedCommand, u.privKey, u.nukiPubKey)
+	authId, decryptedCommand := command.DecryptCommandWithSharedKey(complete, u.sharedKey)
 
 	if decryptedCommand == nil {
-		u.errorChan <- DecryptionError
+		u.disp.deliverError(DecryptionError)
 		return
 	}
 	if logger.Info != nil {
@@ -104,20 +127,34 @@ func (u *udioCommunicator) receive(payload []byte) {
 	}
 
 	if u.authId != authId {
-		u.errorChan <- UnexpectedAuthId
+		u.disp.deliverError(UnexpectedAuthId)
+		return
+	}
+
+	//the nonce is only tracked after the message was authenticated (decrypted) successfully
+	if !u.trackNonce(complete[:nonceSize]) {
+		u.disp.deliverError(ReplayedMessageError)
 		return
 	}
 
 	if !decryptedCommand.CheckCRC() {
-		u.errorChan <- ERROR_BAD_CRC
+		u.disp.deliverError(ERROR_BAD_CRC)
 		return
 	}
 
-	u.commandChan <- decryptedCommand
-	u.curEncryptedCommand = []byte{} //clear command
+	u.disp.deliver(decryptedCommand, "[UDIO][IN]")
+}
+
+func (u *udioCommunicator) trackNonce(nonce []byte) bool {
+	u.noncesMu.Lock()
+	defer u.noncesMu.Unlock()
+
+	return u.nonces.track(nonce)
 }
 
 func (u *udioCommunicator) Close() error {
+	u.disp.close()
+
 	if err := u.client.Unsubscribe(u.udioChar, true); err != nil {
 		return fmt.Errorf("unable to unsubscribe UDIO: %w", err)
 	}
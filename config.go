@@ -3,13 +3,16 @@ package nuki
 import (
 	"context"
 	"fmt"
+	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
 	"time"
 )
 
 // UpdateTime set the given time on the connected device.
-func (c *Client) UpdateTime(ctx context.Context, pin string, t time.Time) error {
-	parsedPin, err := c.checkPreconditionAndParsePin(pin)
+func (c *Client) UpdateTime(ctx context.Context, pin string, t time.Time, opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
+
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
 		return err
 	}
@@ -19,33 +22,69 @@ func (c *Client) UpdateTime(ctx context.Context, pin string, t time.Time) error
 	})
 }
 
-// ReadConfig will request and return the applied config of the connected device.
-func (c *Client) ReadConfig(ctx context.Context) (command.ConfigCommand, error) {
-	if c.client == nil {
-		return nil, ConnectionNotEstablishedError
-	}
-	if c.udioCom == nil {
-		return nil, UnauthenticatedError
-	}
+// SetTimeZone sets the time zone of the connected device to the given location. The time zone id, timezone offset and
+// dst mode will be written together (see command.ConfigCommand.WithTimeZone). All other settings of the current config
+// stay untouched.
+func (c *Client) SetTimeZone(ctx context.Context, pin string, location *time.Location, opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
 
-	err := c.udioCom.Send(command.NewRequest(command.IdChallenge))
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
-		return nil, fmt.Errorf("unable to send request for challenge: %w", err)
+		return err
+	}
+	if location == nil {
+		return fmt.Errorf("%w: no location given", command.UnknownTimeZoneError)
 	}
 
-	challenge, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
+	config, err := c.ReadConfig(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for challenge: %w", err)
+		return fmt.Errorf("unable to read current config: %w", err)
 	}
-	err = c.udioCom.Send(command.NewRequestConfig(challenge.AsChallengeCommand().Nonce()))
-	if err != nil {
-		return nil, fmt.Errorf("unable to send request for config: %w", err)
+
+	updated := config.WithTimeZone(location)
+	if updated == nil {
+		return fmt.Errorf("unable to set time zone: unknown config type")
 	}
 
-	config, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdConfig, c.responseTimeout)
+	//the cached states may contain the config update count of the previous config
+	defer c.InvalidateCache()
+
+	return c.PerformAction(ctx, func(nonce []byte) command.Command {
+		return command.NewSetConfig(updated, parsedPin, nonce)
+	})
+}
+
+// ReadConfig will request and return the applied config of the connected device.
+func (c *Client) ReadConfig(ctx context.Context, opts ...CallOption) (command.ConfigCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	var result command.ConfigCommand
+
+	err := c.exchange(ctx, priorityNormal, true, func(com communication.Communicator, timeout time.Duration) error {
+		nonce, err := c.requestTrustedChallenge(ctx, com, timeout)
+		if err != nil {
+			return err
+		}
+
+		err = com.Send(command.NewRequestConfig(nonce))
+		if err != nil {
+			return fmt.Errorf("unable to send request for config: %w", err)
+		}
+
+		config, err := com.WaitForSpecificResponse(ctx, command.IdConfig, timeout)
+		if err != nil {
+			return fmt.Errorf("error while waiting for config: %w", err)
+		}
+
+		result, err = config.ParseConfigCommand()
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for config: %w", err)
+		return nil, err
 	}
 
-	return config.AsConfigCommand(), nil
+	return result, nil
 }
@@ -0,0 +1,50 @@
+package nuki
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func configDevice(config []byte) *scriptedCommunicator {
+	return &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdRequestConfig):
+				return []command.Command{command.NewCommand(command.IdConfig, config)}
+			case cmd.Is(command.IdSetConfig):
+				return completed()
+			}
+			return nil
+		},
+	}
+}
+
+func TestClient_SetTimeZone(t *testing.T) {
+	config := make([]byte, 74)
+	copy(config[4:36], "Front door")
+	binary.LittleEndian.PutUint16(config[72:74], uint16(command.TimeZoneIdNone))
+	com := configDevice(config)
+	toTest := connectedTestClient(com)
+
+	location, _ := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, toTest.SetTimeZone(context.Background(), "1234", location))
+
+	setConfig := com.sent[len(com.sent)-1]
+	assert.True(t, setConfig.Is(command.IdSetConfig))
+	assert.Equal(t, "Front door", string(setConfig.Payload()[0:10]))
+	assert.Equal(t, []byte{0x3C, 0x00, byte(command.DaylightSavingTimeModeEuropean)}, []byte(setConfig.Payload()[45:48]))
+	assert.Equal(t, []byte{37, 0x00}, []byte(setConfig.Payload()[53:55]))
+}
+
+func TestClient_SetTimeZone_UnknownConfig(t *testing.T) {
+	toTest := connectedTestClient(configDevice(make([]byte, 73)))
+
+	assert.Error(t, toTest.SetTimeZone(context.Background(), "1234", time.UTC))
+	assert.ErrorIs(t, toTest.SetTimeZone(context.Background(), "1234", nil), command.UnknownTimeZoneError)
+}
@@ -0,0 +1,254 @@
+package nuki
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CredentialsNotFoundError will be returned if there are no stored credentials for the given device
+var CredentialsNotFoundError = fmt.Errorf("no credentials found for device")
+
+// CredentialStore persists the credentials of paired devices. The credentials are identified by the device address.
+type CredentialStore interface {
+	// Save will store the given credentials. Already stored credentials of the same device will be overwritten.
+	Save(credentials *Credentials) error
+
+	// Load will return the stored credentials of the given device. Returns CredentialsNotFoundError if there are none.
+	Load(deviceAddress string) (*Credentials, error)
+
+	// Delete will remove the stored credentials of the given device. Returns CredentialsNotFoundError if there are none.
+	Delete(deviceAddress string) error
+
+	// List will return the stored credentials of all devices.
+	List() ([]*Credentials, error)
+}
+
+// MemoryCredentialStore is a CredentialStore which holds the credentials only in memory.
+type MemoryCredentialStore struct {
+	mu          sync.RWMutex
+	credentials map[string]*Credentials
+}
+
+// NewMemoryCredentialStore creates a new empty in-memory store.
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{
+		credentials: map[string]*Credentials{},
+	}
+}
+
+func (m *MemoryCredentialStore) Save(credentials *Credentials) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.credentials[normalizeAddress(credentials.DeviceAddress)] = credentials.copy()
+	return nil
+}
+
+func (m *MemoryCredentialStore) Load(deviceAddress string) (*Credentials, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	credentials, ok := m.credentials[normalizeAddress(deviceAddress)]
+	if !ok {
+		return nil, CredentialsNotFoundError
+	}
+
+	return credentials.copy(), nil
+}
+
+func (m *MemoryCredentialStore) Delete(deviceAddress string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.credentials[normalizeAddress(deviceAddress)]; !ok {
+		return CredentialsNotFoundError
+	}
+	delete(m.credentials, normalizeAddress(deviceAddress))
+	return nil
+}
+
+func (m *MemoryCredentialStore) List() ([]*Credentials, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Credentials, 0, len(m.credentials))
+	for _, credentials := range m.credentials {
+		result = append(result, credentials.copy())
+	}
+	sortCredentials(result)
+
+	return result, nil
+}
+
+// FileCredentialStore is a CredentialStore which holds the credentials of each device in a separate
+// json file inside a directory. The files are only readable by the owner.
+type FileCredentialStore struct {
+	mu        sync.Mutex
+	directory string
+	extension string
+	codec     credentialCodec
+}
+
+// credentialCodec converts the credentials into the content of a credential file and vice versa.
+type credentialCodec interface {
+	encode(credentials *Credentials) ([]byte, error)
+	decode(content []byte) (*Credentials, error)
+}
+
+// plainCodec stores the credentials as (unencrypted) json.
+type plainCodec struct{}
+
+func (plainCodec) encode(credentials *Credentials) ([]byte, error) {
+	content, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize credentials: %w", err)
+	}
+	return content, nil
+}
+
+func (plainCodec) decode(content []byte) (*Credentials, error) {
+	credentials := &Credentials{}
+	if err := json.Unmarshal(content, credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// NewFileCredentialStore creates a new store which uses the given directory. The directory will be created if necessary.
+func NewFileCredentialStore(directory string) (*FileCredentialStore, error) {
+	return newFileCredentialStore(directory, credentialFileExtension, plainCodec{})
+}
+
+func newFileCredentialStore(directory, extension string, codec credentialCodec) (*FileCredentialStore, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create credential directory: %w", err)
+	}
+
+	return &FileCredentialStore{
+		directory: directory,
+		extension: extension,
+		codec:     codec,
+	}, nil
+}
+
+func (f *FileCredentialStore) Save(credentials *Credentials) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := f.codec.encode(credentials)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(f.path(credentials.DeviceAddress), content)
+}
+
+func (f *FileCredentialStore) Load(deviceAddress string) (*Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.load(f.path(deviceAddress))
+}
+
+func (f *FileCredentialStore) Delete(deviceAddress string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := os.Remove(f.path(deviceAddress))
+	if os.IsNotExist(err) {
+		return CredentialsNotFoundError
+	}
+	return err
+}
+
+func (f *FileCredentialStore) List() ([]*Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	files, err := f.files()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Credentials, 0, len(files))
+	for _, file := range files {
+		credentials, err := f.load(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load %s: %w", file, err)
+		}
+		result = append(result, credentials)
+	}
+	sortCredentials(result)
+
+	return result, nil
+}
+
+const credentialFileExtension = ".json"
+
+func (f *FileCredentialStore) path(deviceAddress string) string {
+	name := strings.ReplaceAll(normalizeAddress(deviceAddress), ":", "")
+	return filepath.Join(f.directory, name+f.extension)
+}
+
+func (f *FileCredentialStore) files() ([]string, error) {
+	return filepath.Glob(filepath.Join(f.directory, "*"+f.extension))
+}
+
+func (f *FileCredentialStore) load(path string) (*Credentials, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, CredentialsNotFoundError
+	} else if err != nil {
+		return nil, err
+	}
+
+	return f.codec.decode(content)
+}
+
+// writeFileAtomic will write the content into a temporary file and move it afterwards. So that the target
+// file will never be half written.
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := writeTempFile(filepath.Dir(path), content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Rename(tmp, path)
+}
+
+// writeTempFile will write the content into a new temporary file (only readable by the owner) inside the given
+// directory. The caller is responsible for moving or removing the file.
+func writeTempFile(directory string, content []byte) (string, error) {
+	tmp, err := os.CreateTemp(directory, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("unable to create temporary file: %w", err)
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("unable to change permissions: %w", err)
+	}
+
+	return tmp.Name(), nil
+}
+
+func sortCredentials(credentials []*Credentials) {
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].DeviceAddress < credentials[j].DeviceAddress
+	})
+}
@@ -0,0 +1,211 @@
+package nuki
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-ble/ble"
+	"github.com/kevinburke/nacl"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"strings"
+	"time"
+)
+
+// CredentialsVersion is the current version of the serialized credentials format.
+const CredentialsVersion = 1
+
+// UnsupportedCredentialsVersionError will be returned if serialized credentials have an unknown version
+var UnsupportedCredentialsVersionError = fmt.Errorf("unsupported credentials version")
+
+// InvalidCredentialsError will be returned if serialized credentials are malformed
+var InvalidCredentialsError = fmt.Errorf("invalid credentials")
+
+// Credentials contains all information which are generated while pairing and are necessary for further
+// communication with the device. The credentials should be stored after pairing (see CredentialStore).
+type Credentials struct {
+	DeviceAddress string
+	DeviceType    communication.DeviceType
+	AuthId        command.AuthorizationId
+	AppId         command.ClientId
+	// DeviceUUID is the uuid of the device which was received while pairing.
+	DeviceUUID    []byte
+	PrivateKey    nacl.Key
+	PublicKey     nacl.Key
+	NukiPublicKey []byte
+	PairedAt      time.Time
+}
+
+// credentialsV1 is the serialized format of the credentials in version 1
+type credentialsV1 struct {
+	Version       int       `json:"version"`
+	DeviceAddress string    `json:"deviceAddress"`
+	DeviceType    uint8     `json:"deviceType"`
+	AuthId        uint32    `json:"authId"`
+	AppId         uint32    `json:"appId"`
+	DeviceUUID    string    `json:"deviceUuid,omitempty"`
+	PrivateKey    string    `json:"privateKey"`
+	PublicKey     string    `json:"publicKey"`
+	NukiPublicKey string    `json:"nukiPublicKey"`
+	PairedAt      time.Time `json:"pairedAt"`
+}
+
+// Address returns the bluetooth address of the device.
+func (c *Credentials) Address() ble.Addr {
+	return ble.NewAddr(c.DeviceAddress)
+}
+
+// DeviceConfig returns a configuration for the Manager based on these credentials. The keys will be copied, so the
+// credentials can be wiped afterwards (see Zero).
+func (c *Credentials) DeviceConfig(id DeviceId, name string) DeviceConfig {
+	return DeviceConfig{
+		Id:            id,
+		Name:          name,
+		Address:       c.Address(),
+		Type:          c.DeviceType,
+		AuthId:        c.AuthId,
+		PrivateKey:    copyKey(c.PrivateKey),
+		PublicKey:     copyKey(c.PublicKey),
+		NukiPublicKey: copyBytes(c.NukiPublicKey),
+	}
+}
+
+// Zero will wipe all keys of these credentials from memory. The credentials are unusable afterwards.
+// A Client never shares its keys with credentials (they are copied in both directions). So wiping the credentials
+// does not affect a client which was authenticated with them.
+func (c *Credentials) Zero() {
+	ZeroKey(c.PrivateKey)
+	ZeroKey(c.PublicKey)
+	ZeroBytes(c.NukiPublicKey)
+}
+
+func (c Credentials) MarshalJSON() ([]byte, error) {
+	if c.PrivateKey == nil || c.PublicKey == nil {
+		return nil, InvalidCredentialsError
+	}
+
+	return json.Marshal(credentialsV1{
+		Version:       CredentialsVersion,
+		DeviceAddress: normalizeAddress(c.DeviceAddress),
+		DeviceType:    uint8(c.DeviceType),
+		AuthId:        uint32(c.AuthId),
+		AppId:         uint32(c.AppId),
+		DeviceUUID:    hex.EncodeToString(c.DeviceUUID),
+		PrivateKey:    hex.EncodeToString((*c.PrivateKey)[:]),
+		PublicKey:     hex.EncodeToString((*c.PublicKey)[:]),
+		NukiPublicKey: hex.EncodeToString(c.NukiPublicKey),
+		PairedAt:      c.PairedAt,
+	})
+}
+
+func (c *Credentials) UnmarshalJSON(data []byte) error {
+	version := struct {
+		Version int `json:"version"`
+	}{}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return fmt.Errorf("%w: %s", InvalidCredentialsError, err.Error())
+	}
+	if version.Version != CredentialsVersion {
+		return fmt.Errorf("%w: %d", UnsupportedCredentialsVersionError, version.Version)
+	}
+
+	raw := credentialsV1{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: %s", InvalidCredentialsError, err.Error())
+	}
+
+	privateKey, err := decodeKey(raw.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("%w: private key: %s", InvalidCredentialsError, err.Error())
+	}
+	publicKey, err := decodeKey(raw.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: public key: %s", InvalidCredentialsError, err.Error())
+	}
+	nukiPublicKey, err := hex.DecodeString(raw.NukiPublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: nuki public key: %s", InvalidCredentialsError, err.Error())
+	}
+	var deviceUUID []byte
+	if raw.DeviceUUID != "" {
+		deviceUUID, err = hex.DecodeString(raw.DeviceUUID)
+		if err != nil {
+			return fmt.Errorf("%w: device uuid: %s", InvalidCredentialsError, err.Error())
+		}
+	}
+
+	*c = Credentials{
+		DeviceAddress: normalizeAddress(raw.DeviceAddress),
+		DeviceType:    communication.DeviceType(raw.DeviceType),
+		AuthId:        command.AuthorizationId(raw.AuthId),
+		AppId:         command.ClientId(raw.AppId),
+		DeviceUUID:    deviceUUID,
+		PrivateKey:    privateKey,
+		PublicKey:     publicKey,
+		NukiPublicKey: nukiPublicKey,
+		PairedAt:      raw.PairedAt,
+	}
+	return nil
+}
+
+// copy returns a deep copy of these credentials. So wiping one of them (see Zero) does not affect the other.
+func (c *Credentials) copy() *Credentials {
+	result := *c
+	result.DeviceUUID = copyBytes(c.DeviceUUID)
+	result.PrivateKey = copyKey(c.PrivateKey)
+	result.PublicKey = copyKey(c.PublicKey)
+	result.NukiPublicKey = copyBytes(c.NukiPublicKey)
+	return &result
+}
+
+// ZeroKey will overwrite the given key with zeros.
+func ZeroKey(key nacl.Key) {
+	if key != nil {
+		ZeroBytes(key[:])
+	}
+}
+
+// ZeroBytes will overwrite the given bytes with zeros.
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// copyKey returns a deep copy of the given key (or nil).
+func copyKey(key nacl.Key) nacl.Key {
+	if key == nil {
+		return nil
+	}
+	result := new([nacl.KeySize]byte)
+	*result = *key
+	return result
+}
+
+// copyBytes returns a deep copy of the given bytes (or nil).
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func decodeKey(s string) (nacl.Key, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroBytes(raw)
+
+	if len(raw) != nacl.KeySize {
+		return nil, fmt.Errorf("unexpected key length: %d", len(raw))
+	}
+
+	key := new([nacl.KeySize]byte)
+	copy(key[:], raw)
+	return key, nil
+}
+
+func normalizeAddress(address string) string {
+	return strings.ToLower(address)
+}
@@ -0,0 +1,231 @@
+package nuki
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/kevinburke/nacl"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testCredentials() *Credentials {
+	privateKey, _ := hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	publicKey, _ := hex.DecodeString("F88127CCF48023B5CBE9101D24BAA8A368DA94E8C2E3CDE2DED29CE96AB50C15")
+	nukiPublicKey, _ := hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+
+	return &Credentials{
+		DeviceAddress: "54:d2:aa:bb:cc:dd",
+		DeviceType:    communication.DeviceTypeSmartLock,
+		AuthId:        2,
+		AppId:         13,
+		PrivateKey:    nacl.Key(privateKey),
+		PublicKey:     nacl.Key(publicKey),
+		NukiPublicKey: nukiPublicKey,
+		PairedAt:      time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCredentials_JSON(t *testing.T) {
+	serialized, err := json.Marshal(testCredentials())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":1,"deviceAddress":"54:d2:aa:bb:cc:dd","deviceType":1,"authId":2,"appId":13,`+
+		`"privateKey":"8caa54672307bffdf5ea183fc607158d2011d008eca6a1088614ff0853a5aa07",`+
+		`"publicKey":"f88127ccf48023b5cbe9101d24baa8a368da94e8c2e3cde2ded29ce96ab50c15",`+
+		`"nukiPublicKey":"2fe57da347cd62431528daac5fbb290730fff684afc4cfc2ed90995f58cb3b74",`+
+		`"pairedAt":"2022-06-01T12:00:00Z"}`, string(serialized))
+
+	result := &Credentials{}
+	assert.NoError(t, json.Unmarshal(serialized, result))
+	assert.Equal(t, testCredentials(), result)
+}
+
+func TestCredentials_UnsupportedVersion(t *testing.T) {
+	err := json.Unmarshal([]byte(`{"version":42}`), &Credentials{})
+
+	assert.ErrorIs(t, err, UnsupportedCredentialsVersionError)
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	toTest, err := NewFileCredentialStore(t.TempDir())
+	assert.NoError(t, err)
+
+	_, err = toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.Equal(t, CredentialsNotFoundError, err)
+
+	assert.NoError(t, toTest.Save(testCredentials()))
+
+	loaded, err := toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.NoError(t, err)
+	assert.Equal(t, testCredentials(), loaded)
+
+	all, err := toTest.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Credentials{testCredentials()}, all)
+
+	assert.NoError(t, toTest.Delete("54:D2:AA:BB:CC:DD"))
+	assert.Equal(t, CredentialsNotFoundError, toTest.Delete("54:D2:AA:BB:CC:DD"))
+}
+
+func TestMemoryCredentialStore(t *testing.T) {
+	toTest := NewMemoryCredentialStore()
+
+	assert.NoError(t, toTest.Save(testCredentials()))
+
+	loaded, err := toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.NoError(t, err)
+	assert.Equal(t, testCredentials(), loaded)
+
+	assert.NoError(t, toTest.Delete("54:D2:AA:BB:CC:DD"))
+	_, err = toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.Equal(t, CredentialsNotFoundError, err)
+}
+
+func TestMemoryCredentialStore_Copies(t *testing.T) {
+	toTest := NewMemoryCredentialStore()
+
+	saved := testCredentials()
+	saved.DeviceUUID = []byte{0x01, 0x02}
+	assert.NoError(t, toTest.Save(saved))
+	saved.Zero()
+
+	loaded, err := toTest.Load(saved.DeviceAddress)
+	assert.NoError(t, err)
+	expected := testCredentials()
+	expected.DeviceUUID = []byte{0x01, 0x02}
+	assert.Equal(t, expected, loaded)
+
+	loaded.Zero()
+	listed, err := toTest.List()
+	assert.NoError(t, err)
+	if assert.Len(t, listed, 1) {
+		assert.Equal(t, expected, listed[0])
+		listed[0].Zero()
+	}
+
+	loaded, err = toTest.Load(saved.DeviceAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, loaded)
+}
+
+func TestEncryptedCredentialStore(t *testing.T) {
+	defer func(params scryptParams) { defaultScryptParams = params }(defaultScryptParams)
+	defaultScryptParams = scryptParams{N: 1 << 10, R: 8, P: 1}
+
+	dir := t.TempDir()
+	toTest, err := NewEncryptedCredentialStore(dir, []byte("secret"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, toTest.Save(testCredentials()))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "8caa54672307bffdf5ea183fc607158d2011d008eca6a1088614ff0853a5aa07")
+
+	loaded, err := toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.NoError(t, err)
+	assert.Equal(t, testCredentials(), loaded)
+
+	wrong, err := NewEncryptedCredentialStore(dir, []byte("wrong"))
+	assert.NoError(t, err)
+	_, err = wrong.Load("54:D2:AA:BB:CC:DD")
+	assert.ErrorIs(t, err, WrongPassphraseError)
+
+	assert.NoError(t, toTest.RotatePassphrase([]byte("new secret")))
+	loaded, err = toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.NoError(t, err)
+	assert.Equal(t, testCredentials(), loaded)
+
+	old, err := NewEncryptedCredentialStore(dir, []byte("secret"))
+	assert.NoError(t, err)
+	_, err = old.Load("54:D2:AA:BB:CC:DD")
+	assert.ErrorIs(t, err, WrongPassphraseError)
+	assert.ErrorIs(t, old.RotatePassphrase([]byte("other")), WrongPassphraseError)
+
+	rotated, err := NewEncryptedCredentialStore(dir, []byte("new secret"))
+	assert.NoError(t, err)
+	all, err := rotated.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Credentials{testCredentials()}, all)
+
+	toTest.Zero()
+	_, err = toTest.Load("54:D2:AA:BB:CC:DD")
+	assert.ErrorIs(t, err, CredentialStoreZeroedError)
+}
+
+func TestCredentials_Zero(t *testing.T) {
+	toTest := testCredentials()
+
+	toTest.Zero()
+
+	assert.Equal(t, [nacl.KeySize]byte{}, *toTest.PrivateKey)
+	assert.Equal(t, [nacl.KeySize]byte{}, *toTest.PublicKey)
+	assert.Equal(t, make([]byte, 32), toTest.NukiPublicKey)
+}
+
+func TestCredentials_JSON_DeviceUUID(t *testing.T) {
+	credentials := testCredentials()
+	credentials.DeviceUUID, _ = hex.DecodeString("3f2a1c2b8e0d4f6a9b7c5d3e1f2a4b6c")
+
+	serialized, err := json.Marshal(credentials)
+	assert.NoError(t, err)
+	assert.Contains(t, string(serialized), `"deviceUuid":"3f2a1c2b8e0d4f6a9b7c5d3e1f2a4b6c"`)
+
+	result := &Credentials{}
+	assert.NoError(t, json.Unmarshal(serialized, result))
+	assert.Equal(t, credentials, result)
+}
+
+func TestClient_Credentials_Copies(t *testing.T) {
+	credentials := testCredentials()
+	client := NewClient(nil)
+
+	err := client.Authenticate(credentials.PrivateKey, credentials.PublicKey, credentials.NukiPublicKey, credentials.AuthId)
+	assert.ErrorIs(t, err, ConnectionNotEstablishedError)
+
+	credentials.Zero()
+	assert.Equal(t, testCredentials().PrivateKey, client.Credentials().PrivateKey, "the keys of the client must not be wiped")
+
+	client.Credentials().Zero()
+	assert.Equal(t, testCredentials().PrivateKey, client.Credentials().PrivateKey)
+	assert.Equal(t, testCredentials().PublicKey, client.Credentials().PublicKey)
+	assert.Equal(t, testCredentials().NukiPublicKey, client.Credentials().NukiPublicKey)
+}
+
+func TestEncryptedCredentialStore_ScryptParamsTooHigh(t *testing.T) {
+	toTest := newEncryptedCodec([]byte("secret"))
+
+	for _, params := range []scryptParams{{N: 1 << 30, R: 8, P: 1}, {N: 1 << 10, R: 1 << 20, P: 1}, {N: 1 << 10, R: 8, P: 1 << 20}} {
+		content, _ := json.Marshal(encryptedCredentialsV1{
+			Version: CredentialsVersion,
+			Kdf:     "scrypt",
+			N:       params.N,
+			R:       params.R,
+			P:       params.P,
+			Salt:    "00",
+			Box:     "00",
+		})
+
+		_, err := toTest.decode(content)
+		assert.ErrorIs(t, err, InvalidCredentialsError)
+	}
+}
+
+func TestCredentials_JSON_Value(t *testing.T) {
+	fromPointer, err := json.Marshal(testCredentials())
+	assert.NoError(t, err)
+
+	fromValue, err := json.Marshal(*testCredentials())
+	assert.NoError(t, err)
+	assert.Equal(t, string(fromPointer), string(fromValue))
+
+	embedded, err := json.Marshal(struct{ Credentials Credentials }{*testCredentials()})
+	assert.NoError(t, err)
+	assert.Contains(t, string(embedded), `"version":1`)
+}
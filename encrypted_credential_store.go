@@ -0,0 +1,267 @@
+package nuki
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"os"
+)
+
+// WrongPassphraseError will be returned if an encrypted credential file can not be decrypted with the passphrase
+var WrongPassphraseError = fmt.Errorf("unable to decrypt credentials: wrong passphrase or corrupted file")
+
+// CredentialStoreZeroedError will be returned if an encrypted store is used after its passphrase was wiped
+var CredentialStoreZeroedError = fmt.Errorf("the passphrase of the credential store was wiped")
+
+const encryptedCredentialFileExtension = ".json.enc"
+
+const scryptSaltSize = 16
+
+// scryptParams are the cost parameters of the key derivation. They are stored in each file, so that they can be
+// raised later without breaking existing files.
+type scryptParams struct {
+	N int
+	R int
+	P int
+}
+
+// defaultScryptParams are the recommended parameters for interactive logins (see scrypt.Key).
+var defaultScryptParams = scryptParams{N: 1 << 15, R: 8, P: 1}
+
+// maxScryptParams are the highest parameters which will be accepted from a file. The memory usage of the key
+// derivation is about 128*N*R bytes (256 MiB at most). So a crafted file can not exhaust the memory or cpu.
+var maxScryptParams = scryptParams{N: 1 << 18, R: 8, P: 16}
+
+func (p scryptParams) validate() error {
+	if p.N > maxScryptParams.N || p.R > maxScryptParams.R || p.P > maxScryptParams.P {
+		return fmt.Errorf("%w: scrypt parameters exceed the maximum (n=%d, r=%d, p=%d)", InvalidCredentialsError, p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// encryptedCredentialsV1 is the content of an encrypted credential file in version 1
+type encryptedCredentialsV1 struct {
+	Version int    `json:"version"`
+	Kdf     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    string `json:"salt"`
+	// Box contains the nonce followed by the encrypted (serialized) credentials
+	Box string `json:"box"`
+}
+
+// EncryptedCredentialStore is a CredentialStore which holds the credentials of each device in a separate
+// encrypted file inside a directory. The key is derived from a passphrase (scrypt) with a random salt per file and
+// the credentials are encrypted with secretbox. The store holds a copy of the passphrase: call Zero to wipe it
+// from memory if the store is not used anymore.
+type EncryptedCredentialStore struct {
+	*FileCredentialStore
+	codec *encryptedCodec
+}
+
+// NewEncryptedCredentialStore creates a new store which uses the given directory and passphrase. The directory will
+// be created if necessary. The passphrase will be copied, so the caller can wipe its own copy afterwards.
+func NewEncryptedCredentialStore(directory string, passphrase []byte) (*EncryptedCredentialStore, error) {
+	codec := newEncryptedCodec(passphrase)
+
+	store, err := newFileCredentialStore(directory, encryptedCredentialFileExtension, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedCredentialStore{
+		FileCredentialStore: store,
+		codec:               codec,
+	}, nil
+}
+
+// RotatePassphrase will re-encrypt all stored credentials with the new passphrase. All files will be decrypted
+// before anything is written. So if one file can not be decrypted, nothing will be changed.
+func (e *EncryptedCredentialStore) RotatePassphrase(newPassphrase []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	files, err := e.files()
+	if err != nil {
+		return err
+	}
+
+	newCodec := newEncryptedCodec(newPassphrase)
+	rotated := make(map[string]string, len(files))
+	defer func() {
+		//remove all leftovers if something went wrong
+		for _, tmp := range rotated {
+			os.Remove(tmp)
+		}
+	}()
+
+	for _, file := range files {
+		credentials, err := e.load(file)
+		if err != nil {
+			newCodec.zero()
+			return fmt.Errorf("unable to load %s: %w", file, err)
+		}
+
+		content, err := newCodec.encode(credentials)
+		credentials.Zero()
+		if err != nil {
+			newCodec.zero()
+			return err
+		}
+
+		tmp, err := writeTempFile(e.directory, content)
+		if err != nil {
+			newCodec.zero()
+			return err
+		}
+		rotated[file] = tmp
+	}
+
+	for file, tmp := range rotated {
+		if err := os.Rename(tmp, file); err != nil {
+			//the already replaced files are encrypted with the new passphrase, the remaining ones with the old one
+			e.codec.replace(newCodec)
+			return fmt.Errorf("unable to replace %s: %w", file, err)
+		}
+		delete(rotated, file)
+	}
+
+	e.codec.replace(newCodec)
+	return nil
+}
+
+// Zero will wipe the passphrase from memory. The store is unusable afterwards.
+func (e *EncryptedCredentialStore) Zero() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.codec.zero()
+}
+
+// encryptedCodec encrypts the serialized credentials with a key derived from the passphrase.
+type encryptedCodec struct {
+	passphrase []byte
+	params     scryptParams
+}
+
+func newEncryptedCodec(passphrase []byte) *encryptedCodec {
+	return &encryptedCodec{
+		passphrase: append([]byte{}, passphrase...),
+		params:     defaultScryptParams,
+	}
+}
+
+func (e *encryptedCodec) encode(credentials *Credentials) ([]byte, error) {
+	if e.passphrase == nil {
+		return nil, CredentialStoreZeroedError
+	}
+
+	plain, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize credentials: %w", err)
+	}
+	defer ZeroBytes(plain)
+
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("unable to generate salt: %w", err)
+	}
+
+	key, err := e.deriveKey(salt, e.params)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroKey(key)
+
+	content, err := json.MarshalIndent(encryptedCredentialsV1{
+		Version: CredentialsVersion,
+		Kdf:     "scrypt",
+		N:       e.params.N,
+		R:       e.params.R,
+		P:       e.params.P,
+		Salt:    hex.EncodeToString(salt),
+		Box:     hex.EncodeToString(secretbox.EasySeal(plain, key)),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize encrypted credentials: %w", err)
+	}
+	return content, nil
+}
+
+func (e *encryptedCodec) decode(content []byte) (*Credentials, error) {
+	if e.passphrase == nil {
+		return nil, CredentialStoreZeroedError
+	}
+
+	raw := encryptedCredentialsV1{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %s", InvalidCredentialsError, err.Error())
+	}
+	if raw.Version != CredentialsVersion {
+		return nil, fmt.Errorf("%w: %d", UnsupportedCredentialsVersionError, raw.Version)
+	}
+	if raw.Kdf != "scrypt" {
+		return nil, fmt.Errorf("%w: unsupported key derivation function: %s", InvalidCredentialsError, raw.Kdf)
+	}
+
+	salt, err := hex.DecodeString(raw.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt: %s", InvalidCredentialsError, err.Error())
+	}
+	box, err := hex.DecodeString(raw.Box)
+	if err != nil {
+		return nil, fmt.Errorf("%w: box: %s", InvalidCredentialsError, err.Error())
+	}
+
+	params := scryptParams{N: raw.N, R: raw.R, P: raw.P}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	key, err := e.deriveKey(salt, params)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroKey(key)
+
+	plain, err := secretbox.EasyOpen(box, key)
+	if err != nil {
+		return nil, WrongPassphraseError
+	}
+	defer ZeroBytes(plain)
+
+	credentials := &Credentials{}
+	if err := json.Unmarshal(plain, credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (e *encryptedCodec) deriveKey(salt []byte, params scryptParams) (nacl.Key, error) {
+	derived, err := scrypt.Key(e.passphrase, salt, params.N, params.R, params.P, nacl.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to derive key: %s", InvalidCredentialsError, err.Error())
+	}
+	defer ZeroBytes(derived)
+
+	key := new([nacl.KeySize]byte)
+	copy(key[:], derived)
+	return key, nil
+}
+
+// replace will take over the passphrase of the other codec. The old passphrase will be wiped.
+func (e *encryptedCodec) replace(other *encryptedCodec) {
+	ZeroBytes(e.passphrase)
+	e.passphrase = other.passphrase
+	e.params = other.params
+}
+
+func (e *encryptedCodec) zero() {
+	ZeroBytes(e.passphrase)
+	e.passphrase = nil
+}
@@ -0,0 +1,183 @@
+package nuki
+
+import (
+	"context"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the count of events which can be buffered for each subscriber before further events will be dropped.
+const eventBufferSize = 32
+
+type EventType uint8
+
+const (
+	// EventTypeStates signals that the device has sent its states (for example while the motor is moving)
+	EventTypeStates = EventType(0x01)
+	// EventTypeStatus signals that the device has sent a status (for example the completion of a lock action)
+	EventTypeStatus = EventType(0x02)
+	// EventTypeError signals that the device has reported an error
+	EventTypeError = EventType(0x03)
+	// EventTypeConnection signals that the state of the connection has changed
+	EventTypeConnection = EventType(0x04)
+	// EventTypeInvalidTransition signals that the received states contain a lock state which can not follow the
+	// previously received lock state (see IsValidLockStateTransition)
+	EventTypeInvalidTransition = EventType(0x05)
+	// EventTypeStatesChanged signals that the cached states have changed (see WithCache)
+	EventTypeStatesChanged = EventType(0x06)
+	// EventTypeConfigChanged signals that the cached config has changed (see WithCache)
+	EventTypeConfigChanged = EventType(0x07)
+)
+
+// Event is a notification which was sent by the connected device. Events will be delivered regardless of whether
+// they are answers of a request or they are sent unsolicited by the device.
+type Event struct {
+	Type       EventType
+	ReceivedAt time.Time
+	DeviceType communication.DeviceType
+
+	// Command is the raw command which was received
+	Command command.Command
+	// Err contains the reported error if the Type is EventTypeError or an *InvalidTransitionError if the Type is
+	// EventTypeInvalidTransition
+	Err error
+	// ConnectionState contains the new state of the connection if the Type is EventTypeConnection
+	ConnectionState ConnectionState
+}
+
+// States returns the received states if the event is of type EventTypeStates or EventTypeStatesChanged. Otherwise nil.
+func (e Event) States() command.StatesCommand {
+	return e.Command.AsStatesCommand()
+}
+
+// Config returns the changed config if the event is of type EventTypeConfigChanged. Otherwise nil.
+func (e Event) Config() command.ConfigCommand {
+	return e.Command.AsConfigCommand()
+}
+
+// Status returns the received status if the event is of type EventTypeStatus. Otherwise nil.
+func (e Event) Status() command.StatusCommand {
+	return e.Command.AsStatusCommand()
+}
+
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	// onStates will be called with all received states (after they were published)
+	onStates func(states command.StatesCommand)
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+func (h *eventHub) subscribe(ctx context.Context) <-chan Event {
+	sub := make(chan Event, eventBufferSize)
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers, sub)
+		close(sub)
+	}()
+
+	return sub
+}
+
+func (h *eventHub) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		select {
+		case sub <- e:
+		default:
+			if logger.Debug != nil {
+				logger.Debug.Printf("[EVENT] Subscriber is too slow. Drop event of type: 0x%02x", e.Type)
+			}
+		}
+	}
+}
+
+// forward will publish all relevant commands of the given communicator until the communicator is closed. Received
+// states will be checked against the previous received states of this communicator. Keep in mind that states
+// which were never received (because the device did not push them) may lead to a false invalid transition.
+func (h *eventHub) forward(com communication.Communicator) {
+	commands := com.Subscribe(context.Background())
+
+	go func() {
+		transitions := transitionTracker{}
+
+		for cmd := range commands {
+			if err := cmd.Validate(); err != nil {
+				if logger.Info != nil {
+					logger.Info.Printf("[EVENT] Drop invalid command: %s", err.Error())
+				}
+				continue
+			}
+
+			e := Event{
+				ReceivedAt: time.Now(),
+				DeviceType: com.GetDeviceType(),
+				Command:    cmd,
+			}
+
+			switch cmd.Id() {
+			case command.IdStates:
+				e.Type = EventTypeStates
+			case command.IdStatus:
+				e.Type = EventTypeStatus
+			case command.IdErrorReport:
+				e.Type = EventTypeError
+				e.Err = communication.Error(cmd, e.DeviceType)
+			default:
+				continue
+			}
+
+			h.publish(e)
+
+			if e.Type == EventTypeStates {
+				h.checkTransition(&transitions, e)
+				if h.onStates != nil {
+					h.onStates(e.States())
+				}
+			}
+		}
+	}()
+}
+
+func (h *eventHub) checkTransition(transitions *transitionTracker, e Event) {
+	err := transitions.observe(e.DeviceType, e.States())
+	if err == nil {
+		return
+	}
+
+	if logger.Info != nil {
+		logger.Info.Printf("[EVENT] %s", err.Error())
+	}
+
+	e.Type = EventTypeInvalidTransition
+	e.Err = err
+	h.publish(e)
+}
+
+// Subscribe will return a channel which receives all events (states, status updates, error reports and connection
+// state changes) of the connected device. This includes the states which will be pushed by the device during motor
+// movement. The subscription is independent of the current connection: it will survive a re-establishing of the
+// connection. The channel will be closed if the given context is done. Slow subscribers will miss events instead of blocking the communication.
+func (c *Client) Subscribe(ctx context.Context) <-chan Event {
+	return c.events.subscribe(ctx)
+}
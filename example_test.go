@@ -8,6 +8,7 @@ import (
 	"github.com/go-ble/ble/linux"
 	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/nacl/box"
+	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
 	"time"
 )
@@ -489,6 +490,42 @@ func ExampleClient_UpdateTime() {
 	}
 }
 
+func ExampleClient_SetTimeZone() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	nukiClient := NewClient(device)
+	defer nukiClient.Close()
+
+	nukiDeviceAddr := ble.NewAddr("54:D2:AA:BB:CC:DD")
+	err = nukiClient.EstablishConnection(context.Background(), nukiDeviceAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	authId := command.AuthorizationId(111111) //load from file
+	privateKey := nacl.Key(make([]byte, 32))  //load from file
+	publicKey := nacl.Key(make([]byte, 32))   //load from file
+	nukiPublicKey := []byte{}                 //load from file
+
+	err = nukiClient.Authenticate(privateKey, publicKey, nukiPublicKey, authId)
+	if err != nil {
+		panic(err)
+	}
+
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	err = nukiClient.SetTimeZone(context.Background(), "0000", location)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func ExampleClient_ReadConfig() {
 	device, err := linux.NewDevice()
 	if err != nil {
@@ -562,3 +599,232 @@ func ExampleClient_Reboot() {
 
 	//do something with the device...
 }
+
+func ExampleClient_Subscribe() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	nukiClient := NewClient(device)
+	defer nukiClient.Close()
+
+	nukiDeviceAddr := ble.NewAddr("54:D2:AA:BB:CC:DD")
+	err = nukiClient.EstablishConnection(context.Background(), nukiDeviceAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	authId := command.AuthorizationId(111111) //load from file
+	privateKey := nacl.Key(make([]byte, 32))  //load from file
+	publicKey := nacl.Key(make([]byte, 32))   //load from file
+	nukiPublicKey := []byte{}                 //load from file
+
+	err = nukiClient.Authenticate(privateKey, publicKey, nukiPublicKey, authId)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for event := range nukiClient.Subscribe(ctx) {
+			switch event.Type {
+			case EventTypeStates:
+				fmt.Printf("States:\n%s\n", event.States())
+			case EventTypeStatus:
+				fmt.Printf("Status: %s\n", event.Status().Status())
+			case EventTypeError:
+				fmt.Printf("Error: %s\n", event.Err)
+			}
+		}
+	}()
+
+	//the pushed states during the motor movement will be received by the subscriber
+	err = nukiClient.PerformUnlock(context.Background(), 13)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func ExampleClient_WithReconnect() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	nukiClient := NewClient(device).WithReconnect(DefaultReconnectPolicy)
+	defer nukiClient.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for event := range nukiClient.Subscribe(ctx) {
+			if event.Type == EventTypeConnection {
+				fmt.Printf("Connection state: 0x%02x\n", event.ConnectionState)
+			}
+		}
+	}()
+
+	nukiDeviceAddr := ble.NewAddr("54:D2:AA:BB:CC:DD")
+	err = nukiClient.EstablishConnection(context.Background(), nukiDeviceAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	authId := command.AuthorizationId(111111) //load from file
+	privateKey := nacl.Key(make([]byte, 32))  //load from file
+	publicKey := nacl.Key(make([]byte, 32))   //load from file
+	nukiPublicKey := []byte{}                 //load from file
+
+	err = nukiClient.Authenticate(privateKey, publicKey, nukiPublicKey, authId)
+	if err != nil {
+		panic(err)
+	}
+
+	err = nukiClient.Reboot(context.Background(), "0000")
+	if err != nil {
+		panic(err)
+	}
+
+	//the connection will be re-established automatically: this call waits until the reconnection is done
+	state, err := nukiClient.ReadStates(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Status:\n%s", state)
+}
+
+func ExampleSession_Do() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	//the adapter can hold only a few connections at the same time
+	limiter := NewConnectionLimiter(3)
+
+	authId := command.AuthorizationId(111111) //load from file
+	privateKey := nacl.Key(make([]byte, 32))  //load from file
+	publicKey := nacl.Key(make([]byte, 32))   //load from file
+	nukiPublicKey := []byte{}                 //load from file
+
+	session := NewSession(device, ble.NewAddr("54:D2:AA:BB:CC:DD"), privateKey, publicKey, nukiPublicKey, authId).
+		WithIdleTimeout(10 * time.Second).
+		WithConnectionLimiter(limiter)
+	defer session.Close()
+
+	//the connection will be established here and will be reused for following calls within the idle timeout
+	err = session.Do(context.Background(), func(client *Client) error {
+		state, err := client.ReadStates(context.Background())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Status:\n%s", state)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func ExampleManager_Do() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	manager := NewManager(device, 3)
+	defer manager.Close()
+
+	err = manager.Register(DeviceConfig{
+		Id:            "front-door",
+		Name:          "Front door",
+		Address:       ble.NewAddr("54:D2:AA:BB:CC:DD"),
+		Type:          communication.DeviceTypeSmartLock,
+		AuthId:        command.AuthorizationId(111111), //load from file
+		PrivateKey:    nacl.Key(make([]byte, 32)),      //load from file
+		PublicKey:     nacl.Key(make([]byte, 32)),      //load from file
+		NukiPublicKey: []byte{},                        //load from file
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = manager.Do(context.Background(), "front-door", func(client *Client) error {
+		return client.PerformLock(context.Background(), 13)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	health, err := manager.Health("front-door")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Last seen: %s; Battery: %d%%\n", health.LastSeen, health.BatteryPercentage)
+}
+
+func ExampleClient_PairAndStore() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	store, err := NewFileCredentialStore("/var/lib/nuki/credentials")
+	if err != nil {
+		panic(err)
+	}
+
+	nukiClient := NewClient(device)
+	defer nukiClient.Close()
+
+	nukiDeviceAddr := ble.NewAddr("54:D2:AA:BB:CC:DD")
+	err = nukiClient.EstablishConnection(context.Background(), nukiDeviceAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	//the key-pair will be generated and the resulting credentials will be saved into the store
+	credentials, err := nukiClient.PairAndStore(context.Background(), store, 13, command.ClientIdTypeApp, "Lib-Nuki-Example")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Paired with authentication id: %d", credentials.AuthId)
+}
+
+func ExampleClient_AuthenticateFromStore() {
+	device, err := linux.NewDevice()
+	if err != nil {
+		panic(err)
+	}
+
+	store, err := NewFileCredentialStore("/var/lib/nuki/credentials")
+	if err != nil {
+		panic(err)
+	}
+
+	nukiClient := NewClient(device)
+	defer nukiClient.Close()
+
+	nukiDeviceAddr := ble.NewAddr("54:D2:AA:BB:CC:DD")
+	err = nukiClient.EstablishConnection(context.Background(), nukiDeviceAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	err = nukiClient.AuthenticateFromStore(store)
+	if err != nil {
+		panic(err)
+	}
+
+	err = nukiClient.PerformUnlock(context.Background(), 13)
+	if err != nil {
+		panic(err)
+	}
+}
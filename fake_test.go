@@ -0,0 +1,90 @@
+package nuki
+
+import (
+	"context"
+	"github.com/go-ble/ble"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"time"
+)
+
+// Disconnected never reports a connection loss: so failed requests will not try to recover the connection.
+func (connectedBleClient) Disconnected() <-chan struct{} {
+	return nil
+}
+
+// Conn returns a connection which can be closed (without any effect).
+func (connectedBleClient) Conn() ble.Conn {
+	return closableConn{}
+}
+
+type closableConn struct {
+	ble.Conn
+}
+
+func (closableConn) Close() error {
+	return nil
+}
+
+func (s *scriptedCommunicator) Close() error {
+	return nil
+}
+
+func (s *scriptedCommunicator) GetDeviceType() communication.DeviceType {
+	return communication.DeviceTypeSmartLock
+}
+
+func (s *scriptedCommunicator) WaitForResponse(context.Context, time.Duration) (command.Command, error) {
+	if len(s.pending) == 0 {
+		return nil, communication.TimeoutErr
+	}
+
+	next := s.pending[0]
+	s.pending = s.pending[1:]
+
+	if next.Is(command.IdErrorReport) {
+		return nil, communication.Error(next, communication.DeviceTypeSmartLock)
+	}
+	return next, nil
+}
+
+func testStates(lockState command.LockState, lastActionStatus command.LockActionCompletionStatus) command.Command {
+	payload := make([]byte, 21)
+	payload[0] = byte(command.NukiStateDoorMode)
+	payload[1] = byte(lockState)
+	payload[2] = byte(command.TriggerSystem)
+	payload[17] = byte(lastActionStatus)
+	payload[18] = byte(command.DoorSensorStateDoorClosed)
+	return command.NewCommand(command.IdKeyturnerStates, payload)
+}
+
+// connectedTestClient returns an authenticated client which communicates via the given communicator.
+func connectedTestClient(com communication.Communicator) *Client {
+	client := NewClient(nil)
+	client.client = connectedBleClient{}
+	client.gdioCom = com
+	client.udioCom = com
+	return client
+}
+
+// fakeSession returns a session which will "connect" the client to the given communicator (without bluetooth).
+func fakeSession(com communication.Communicator) (*Session, *int) {
+	session := NewSession(nil, ble.NewAddr("00:00:00:00:00:01"), nil, nil, nil, 1)
+	connects := fakeEstablish(session, com)
+	return session, connects
+}
+
+// fakeEstablish replaces the connection establishment of the given session. Returns the count of connects.
+func fakeEstablish(session *Session, com communication.Communicator) *int {
+	connects := 0
+	session.establish = func(context.Context) error {
+		connects++
+		session.client.mu.Lock()
+		session.client.client = connectedBleClient{}
+		session.client.gdioCom = com
+		session.client.udioCom = com
+		session.client.mu.Unlock()
+		return nil
+	}
+	return &connects
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/kevinburke/nacl"
 	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"sync"
 	"time"
 )
 
@@ -19,29 +21,60 @@ var UnauthenticatedError = fmt.Errorf("the client is not authenticated")
 // InvalidPinError will be returned if the given pin is invalid
 var InvalidPinError = fmt.Errorf("the given pin is invalid")
 
+// Client is the connection to one nuki device. All methods of the client are safe for concurrent use: the operations
+// will be queued and communicate one after another with the device. Lock-, unlock- and open-actions will be preferred
+// over other operations.
 type Client struct {
+	mu    sync.RWMutex
+	queue scheduler
+
 	client          ble.Client
+	address         ble.Addr
 	responseTimeout time.Duration
 
+	reconnectPolicy *ReconnectPolicy
+	reconnected     chan struct{}
+	stopReconnect   chan struct{}
+
+	retryPolicy *RetryPolicy
+
+	pinned *pinnedIdentity
+
 	privateKey    nacl.Key
 	publicKey     nacl.Key
 	nukiPublicKey []byte
+	sharedKey     nacl.Key
 	authId        command.AuthorizationId
+	appId         command.ClientId
+	deviceUUID    []byte
+	pairedAt      time.Time
 
 	gdioCom communication.Communicator
 	udioCom communication.Communicator
+	// completionPending is true if the last action was accepted but its completion was not received. So the
+	// completion may arrive while the next action is performed.
+	completionPending bool
+
+	events *eventHub
+	cache  *stateCache
 }
 
 func NewClient(bleDevice ble.Device) *Client {
 	ble.SetDefaultDevice(bleDevice)
 
-	return &Client{
+	c := &Client{
 		responseTimeout: 10 * time.Second,
+		events:          newEventHub(),
 	}
+	c.events.onStates = c.storeStates
+	return c
 }
 
 // WithTimeout sets the timeout which is used for each response waiting.
 func (c *Client) WithTimeout(duration time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.responseTimeout = duration
 	return c
 }
@@ -49,131 +82,354 @@ func (c *Client) WithTimeout(duration time.Duration) *Client {
 // EstablishConnection establish a connection to the given nuki device.
 // Returns an error if there was a problem with connecting to the device.
 func (c *Client) EstablishConnection(ctx context.Context, deviceAddress ble.Addr) error {
-	bleClient, err := ble.Dial(ctx, deviceAddress)
+	if err := c.queue.acquire(ctx, priorityHigh); err != nil {
+		return err
+	}
+	defer c.queue.release()
+
+	return c.establishConnection(ctx, deviceAddress)
+}
+
+func (c *Client) establishConnection(ctx context.Context, deviceAddress ble.Addr) error {
+	bleClient, err := dial(ctx, deviceAddress)
 	if err != nil {
 		return fmt.Errorf("error while establish connection: %w", err)
 	}
-	c.client = bleClient
 
-	c.gdioCom, err = communication.NewGeneralDataIOCommunicator(bleClient)
+	gdioCom, err := communication.NewGeneralDataIOCommunicator(bleClient)
 	if err != nil {
+		bleClient.CancelConnection()
 		return fmt.Errorf("error while establish communication: %w", err)
 	}
+	c.events.forward(gdioCom)
+
+	c.mu.Lock()
+	c.client = bleClient
+	c.address = deviceAddress
+	c.gdioCom = gdioCom
+	privateKey, publicKey, nukiPublicKey, authId := c.privateKey, c.publicKey, c.nukiPublicKey, c.authId
+	c.mu.Unlock()
+
+	c.watchConnection(bleClient)
 
 	//in case of "re-establish" a connection (for example after reboot the device)
-	if c.nukiPublicKey != nil {
-		return c.Authenticate(c.privateKey, c.publicKey, c.nukiPublicKey, c.authId)
+	if nukiPublicKey != nil {
+		err = c.authenticate(privateKey, publicKey, nukiPublicKey, authId)
+		if err != nil {
+			return err
+		}
 	}
 
+	c.publishConnectionState(ConnectionStateConnected)
 	return nil
 }
 
+// only for monkey patching purposes
+var dial = ble.Dial
+
 // GetDeviceType will return the discovered type of the connected device.
 func (c *Client) GetDeviceType() communication.DeviceType {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if c.gdioCom == nil {
 		return communication.DeviceTypeUnknown
 	}
 	return c.gdioCom.GetDeviceType()
 }
 
+func (c *Client) isConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client != nil
+}
+
+func (c *Client) isAuthenticated() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.udioCom != nil
+}
+
 // GeneralDataIOCommunicator will return the communicator which is responsible for general data io.
-// This is only available after the connection is established (EstablishConnection)
+// This is only available after the connection is established (EstablishConnection). Be aware that the
+// usage of the communicator bypasses the command queue of the client.
 func (c *Client) GeneralDataIOCommunicator() communication.Communicator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.gdioCom
 }
 
 // UserSpecificDataIOCommunicator will return the communicator which is responsible for user specific data io.
 // This is only available after the connection is established (EstablishConnection) and the authentication is done (Pair or Authenticate).
+// Be aware that the usage of the communicator bypasses the command queue of the client.
 func (c *Client) UserSpecificDataIOCommunicator() communication.Communicator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.udioCom
 }
 
 // Close will close all underlying resources. This function should be called after
 // the client will not be used anymore.
 func (c *Client) Close() error {
-	errors := make([]error, 0, 3)
+	c.mu.Lock()
+	if c.stopReconnect != nil {
+		close(c.stopReconnect)
+		c.stopReconnect = nil
+	}
+	c.mu.Unlock()
 
-	if c.gdioCom != nil {
-		if err := c.gdioCom.Close(); err != nil {
-			errors = append(errors, err)
-		}
-		c.gdioCom = nil
+	if err := c.queue.acquire(context.Background(), priorityHigh); err != nil {
+		return err
 	}
+	defer c.queue.release()
+
+	return c.close()
+}
 
-	if c.udioCom != nil {
-		if err := c.udioCom.Close(); err != nil {
+func (c *Client) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errors := c.closeResources(true)
+	if len(errors) > 0 {
+		return fmt.Errorf("error while closing resources: [%v]", errors)
+	}
+
+	return nil
+}
+
+// closeResources will close the communicators and (if requested) the underlying connection. If the connection is
+// already lost, the communicators will be closed in background. The caller must hold the lock of the client.
+func (c *Client) closeResources(closeConnection bool) []error {
+	errors := make([]error, 0, 3)
+
+	for _, com := range []communication.Communicator{c.gdioCom, c.udioCom} {
+		if com == nil {
+			continue
+		}
+		if !closeConnection {
+			go com.Close()
+			continue
+		}
+		if err := com.Close(); err != nil {
 			errors = append(errors, err)
 		}
-		c.udioCom = nil
 	}
+	c.gdioCom = nil
+	c.udioCom = nil
+	c.completionPending = false
 
-	if c.client != nil {
+	if c.client != nil && closeConnection {
 		if err := c.client.Conn().Close(); err != nil {
 			errors = append(errors, err)
 		}
-		c.client = nil
 	}
+	c.client = nil
 
-	if len(errors) > 0 {
-		return fmt.Errorf("error while closing resources: [%v]", errors)
-	}
-
-	return nil
+	return errors
 }
 
 // PerformAction will request the connected and paired nuki opener to perform the given command.
-func (c *Client) PerformAction(ctx context.Context, actionBuilder func(nonce []byte) command.Command) error {
-	if c.client == nil {
-		return ConnectionNotEstablishedError
-	}
-	if c.udioCom == nil {
-		return UnauthenticatedError
-	}
+func (c *Client) PerformAction(ctx context.Context, actionBuilder func(nonce []byte) command.Command, opts ...CallOption) error {
+	return c.performAction(withCallOptions(ctx, opts), priorityNormal, false, nil, nil, actionBuilder)
+}
 
-	err := c.udioCom.Send(command.NewRequest(command.IdChallenge))
-	if err != nil {
-		return fmt.Errorf("unable to send request for challenge: %w", err)
-	}
+// performAction will send the built action and wait for its completion. If requireAccepted is true, the device
+// must accept the action (status ACCEPTED) before the completion (status COMPLETE) is taken into account, if the
+// completion of a previous action is still pending. So this stale completion can not be taken as success of this
+// action. Otherwise, a completion without acceptance is taken as success (some actions are answered directly).
+// If a retry policy is set, the action will only be retried if notApplied (checked with the current states) is true.
+// See WithRetry.
+// All states which are pushed by the device while performing the action will be passed to onStates (if given).
+func (c *Client) performAction(ctx context.Context, prio priority, requireAccepted bool, notApplied func(states command.StatesCommand) bool, onStates func(states command.StatesCommand), actionBuilder func(nonce []byte) command.Command) error {
+	return c.retry(ctx, c.notAppliedByStates(notApplied), func() error {
+		return c.exchange(ctx, prio, false, func(com communication.Communicator, timeout time.Duration) error {
+			return c.sendAction(ctx, com, timeout, requireAccepted, actionBuilder, onStates)
+		})
+	})
+}
 
-	challenge, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
+func (c *Client) sendAction(ctx context.Context, com communication.Communicator, timeout time.Duration, requireAccepted bool, actionBuilder func(nonce []byte) command.Command, onStates func(states command.StatesCommand)) error {
+	nonce, err := c.requestTrustedChallenge(ctx, com, timeout)
 	if err != nil {
-		return fmt.Errorf("error while waiting for challenge: %w", err)
+		return err
 	}
 
-	toSend := actionBuilder(challenge.AsChallengeCommand().Nonce())
-	err = c.udioCom.Send(toSend)
+	toSend := actionBuilder(nonce)
+	err = com.Send(toSend)
 	if err != nil {
 		return fmt.Errorf("unable to send action: %w", err)
 	}
 
-	status, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdStatus, c.responseTimeout)
+	status, err := waitForStatus(ctx, com, timeout, onStates)
 	if err != nil {
 		return fmt.Errorf("error while waiting for status: %w", err)
 	}
 
-	if status.AsStatusCommand().IsAccepted() {
+	c.mu.Lock()
+	completionPending := c.completionPending
+	c.completionPending = false
+	c.mu.Unlock()
+
+	if requireAccepted && completionPending && status.IsComplete() {
+		if logger.Info != nil {
+			logger.Info.Printf("[UDIO][IN] Skip the completion status of the previous action.")
+		}
+
+		status, err = waitForStatus(ctx, com, timeout, onStates)
+		if err != nil {
+			return fmt.Errorf("error while waiting for status: %w", err)
+		}
+	}
+
+	if status.IsAccepted() {
 		// This will be returned to signal that a command has been accepted but the completion status will be signaled later.
 		// So here we just wait for the second status.
 
-		status, err = c.udioCom.WaitForSpecificResponse(ctx, command.IdStatus, c.responseTimeout)
+		status, err = waitForStatus(ctx, com, timeout, onStates)
 		if err != nil {
+			c.mu.Lock()
+			c.completionPending = true
+			c.mu.Unlock()
 			return fmt.Errorf("error while waiting for status: %w", err)
 		}
 
-		if !status.AsStatusCommand().IsComplete() {
-			return fmt.Errorf("unexpected status: expect 0x%02x got 0x%02x", command.CompletionStatusComplete, status.AsStatusCommand().Status())
+		if !status.IsComplete() {
+			return fmt.Errorf("unexpected status: expect %s got %s", command.CompletionStatusComplete, status.Status())
 		}
 	}
 
 	return nil
 }
 
-func (c *Client) checkPreconditionAndParsePin(pin string) (command.Pin, error) {
+// waitForStatus will wait for the next status. All states which are received meanwhile will be passed to the given function.
+func waitForStatus(ctx context.Context, com communication.Communicator, timeout time.Duration, onStates func(states command.StatesCommand)) (command.StatusCommand, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		resp, err := com.WaitForResponse(ctx, time.Until(deadline))
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Is(command.IdStatus) {
+			status, err := resp.ParseStatusCommand()
+			if err != nil {
+				return nil, fmt.Errorf("invalid status: %w", err)
+			}
+			return status, nil
+		}
+		if states := resp.AsStatesCommand(); states != nil && onStates != nil {
+			onStates(states)
+		}
+	}
+}
+
+// exchange will run the given function as soon as the client has the exclusive access to the
+// user-specific data io communicator. The preconditions (connection and authentication) will be checked before.
+// If the connection is lost while an idempotent exchange is running, it will be replayed once after reconnection.
+// Idempotent exchanges will be retried according to the retry policy (see WithRetry).
+func (c *Client) exchange(ctx context.Context, prio priority, idempotent bool, fn func(com communication.Communicator, timeout time.Duration) error) error {
+	if !idempotent {
+		return c.exchangeWithReplay(ctx, prio, false, fn)
+	}
+
+	return c.retry(ctx, nil, func() error {
+		return c.exchangeWithReplay(ctx, prio, true, fn)
+	})
+}
+
+func (c *Client) exchangeWithReplay(ctx context.Context, prio priority, idempotent bool, fn func(com communication.Communicator, timeout time.Duration) error) error {
+	bleClient, err := c.exchangeOnce(ctx, prio, fn)
+	if err == nil || !idempotent {
+		return err
+	}
+
+	if c.recoverConnection(ctx, bleClient) {
+		if logger.Info != nil {
+			logger.Info.Printf("[CONNECTION] Replay the interrupted request after reconnection.")
+		}
+		_, err = c.exchangeOnce(ctx, prio, fn)
+	}
+
+	return err
+}
+
+func (c *Client) exchangeOnce(ctx context.Context, prio priority, fn func(com communication.Communicator, timeout time.Duration) error) (ble.Client, error) {
+	if err := c.awaitReconnection(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := c.queue.acquire(ctx, prio); err != nil {
+		return nil, err
+	}
+	defer c.queue.release()
+
+	com, timeout, err := c.checkPrecondition(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	bleClient := c.client
+	c.mu.RUnlock()
+
+	return bleClient, fn(com, timeout)
+}
+
+// checkPrecondition returns the communicator and the response timeout (which can be overridden per call, see
+// CallTimeout).
+func (c *Client) checkPrecondition(ctx context.Context) (communication.Communicator, time.Duration, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if c.client == nil {
-		return 0, ConnectionNotEstablishedError
+		return nil, 0, ConnectionNotEstablishedError
 	}
 	if c.udioCom == nil {
-		return 0, UnauthenticatedError
+		return nil, 0, UnauthenticatedError
+	}
+	if timeout := callOptionsFrom(ctx).timeout; timeout > 0 {
+		return c.udioCom, timeout, nil
+	}
+	return c.udioCom, c.responseTimeout, nil
+}
+
+func (c *Client) checkPreconditionAndParsePin(ctx context.Context, pin string) (command.Pin, error) {
+	if _, _, err := c.checkPrecondition(ctx); err != nil && !c.isReconnecting() {
+		return command.Pin{}, err
+	}
+	//if reconnecting, the precondition will be checked again after the reconnection
+
+	if source := callOptionsFrom(ctx).pinSource; source != nil {
+		var err error
+		if pin, err = source(ctx); err != nil {
+			return command.Pin{}, fmt.Errorf("unable to get pin: %w", err)
+		}
 	}
 	return command.NewPin(pin)
 }
+
+// requestChallenge will request a new challenge from the device and return its nonce.
+func requestChallenge(ctx context.Context, com communication.Communicator, timeout time.Duration) ([]byte, error) {
+	err := com.Send(command.NewRequest(command.IdChallenge))
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request for challenge: %w", err)
+	}
+
+	challenge, err := com.WaitForSpecificResponse(ctx, command.IdChallenge, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error while waiting for challenge: %w", err)
+	}
+
+	challengeCommand, err := challenge.ParseChallengeCommand()
+	if err != nil {
+		return nil, fmt.Errorf("invalid challenge: %w", err)
+	}
+
+	return challengeCommand.Nonce(), nil
+}
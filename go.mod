@@ -7,6 +7,7 @@ require (
 	github.com/howeyc/crc16 v0.0.0-20171223171357-2b2a61e366a6
 	github.com/kevinburke/nacl v0.0.0-20210405173606-cd9060f5f776
 	github.com/stretchr/testify v1.7.4
+	golang.org/x/crypto v0.7.0
 )
 
 require (
@@ -17,7 +18,6 @@ require (
 	github.com/mgutz/logxi v0.0.0-20161027140823-aebf8a7d67ab // indirect
 	github.com/pkg/errors v0.8.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/crypto v0.7.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,85 @@
+package nuki
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tarent/go-nuki/communication"
+	"time"
+)
+
+// IdentityMismatchError will be returned if identity pinning is enabled and the connected device does not match
+// the pinned identity. In that case no PIN and no action will be sent to the device.
+var IdentityMismatchError = fmt.Errorf("the connected device does not match the pinned identity")
+
+// pinnedIdentity contains the pairing data the connected device must match.
+type pinnedIdentity struct {
+	address       string
+	deviceType    communication.DeviceType
+	nukiPublicKey []byte
+}
+
+// WithIdentityPinning pins the client to the device of the given (stored) credentials. Before a PIN or an action is
+// sent to the device, the client checks:
+//   - the address of the connection is the one of the credentials
+//   - the device type (which is detected by the services of the connected device) is the one of the credentials
+//   - the client is authenticated with the nuki public key of the credentials (and not with the keys of another
+//     device). This is a check of the client configuration, the device is not involved.
+//   - the device proves the possession of the private key of the pinned public key: it must answer the
+//     encrypted challenge request (which is necessary for each PIN and action) with a challenge which can be
+//     decrypted with the shared key
+//
+// Otherwise, an IdentityMismatchError will be returned. Only the last check is based on a secret of the device. The
+// address and the device type can be spoofed. The uuid of the device is not checked: the device sends it only while
+// pairing.
+func (c *Client) WithIdentityPinning(credentials *Credentials) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pinned = &pinnedIdentity{
+		address:       normalizeAddress(credentials.DeviceAddress),
+		deviceType:    credentials.DeviceType,
+		nukiPublicKey: copyBytes(credentials.NukiPublicKey),
+	}
+	return c
+}
+
+// checkPinnedIdentity compares the current connection and authentication with the pinned identity. The proof of key
+// possession is done by requestTrustedChallenge.
+func (c *Client) checkPinnedIdentity() (*pinnedIdentity, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.pinned == nil {
+		return nil, nil
+	}
+
+	if c.address == nil || normalizeAddress(c.address.String()) != c.pinned.address {
+		return c.pinned, fmt.Errorf("%w: unexpected device address", IdentityMismatchError)
+	}
+	if c.pinned.deviceType != communication.DeviceTypeUnknown && c.gdioCom != nil && c.gdioCom.GetDeviceType() != c.pinned.deviceType {
+		return c.pinned, fmt.Errorf("%w: unexpected device type", IdentityMismatchError)
+	}
+	if !bytes.Equal(c.nukiPublicKey, c.pinned.nukiPublicKey) {
+		return c.pinned, fmt.Errorf("%w: the client is not authenticated with the pinned public key", IdentityMismatchError)
+	}
+
+	return c.pinned, nil
+}
+
+// requestTrustedChallenge will request a new challenge (see requestChallenge). If identity pinning is enabled, the
+// identity of the device will be checked before. A challenge which can not be decrypted (or has an unexpected
+// authorization id) is answered by a device which does not own the pinned key.
+func (c *Client) requestTrustedChallenge(ctx context.Context, com communication.Communicator, timeout time.Duration) ([]byte, error) {
+	pinned, err := c.checkPinnedIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := requestChallenge(ctx, com, timeout)
+	if pinned != nil && (errors.Is(err, communication.DecryptionError) || errors.Is(err, communication.UnexpectedAuthId)) {
+		return nil, fmt.Errorf("%w: the device can not prove the possession of the pinned key: %s", IdentityMismatchError, err.Error())
+	}
+	return nonce, err
+}
@@ -0,0 +1,88 @@
+package nuki
+
+import (
+	"context"
+	"github.com/go-ble/ble"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+type challengeCommunicator struct {
+	communication.Communicator
+	response command.Command
+	err      error
+	sent     []command.Command
+}
+
+func (c *challengeCommunicator) Send(cmd command.Command) error {
+	c.sent = append(c.sent, cmd)
+	return nil
+}
+
+func (c *challengeCommunicator) WaitForSpecificResponse(context.Context, command.Id, time.Duration) (command.Command, error) {
+	return c.response, c.err
+}
+
+func pinnedTestClient() *Client {
+	credentials := testCredentials()
+
+	client := NewClient(nil).WithIdentityPinning(credentials)
+	client.address = ble.NewAddr("54:D2:AA:BB:CC:DD")
+	client.nukiPublicKey = credentials.NukiPublicKey
+	return client
+}
+
+func TestClient_requestTrustedChallenge(t *testing.T) {
+	com := &challengeCommunicator{response: command.NewCommand(command.IdChallenge, make([]byte, 32))}
+
+	nonce, err := pinnedTestClient().requestTrustedChallenge(context.Background(), com, time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 32), nonce)
+}
+
+func TestClient_requestTrustedChallenge_NoKeyPossession(t *testing.T) {
+	com := &challengeCommunicator{err: communication.DecryptionError}
+
+	_, err := pinnedTestClient().requestTrustedChallenge(context.Background(), com, time.Second)
+
+	assert.ErrorIs(t, err, IdentityMismatchError)
+}
+
+func TestClient_requestTrustedChallenge_Mismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Client)
+	}{
+		{"address", func(c *Client) { c.address = ble.NewAddr("54:D2:AA:BB:CC:EE") }},
+		{"device type", func(c *Client) {
+			c.gdioCom = &scriptedCommunicator{}
+			c.pinned.deviceType = communication.DeviceTypeOpener
+		}},
+		{"public key", func(c *Client) { c.nukiPublicKey = make([]byte, 32) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := pinnedTestClient()
+			tt.modify(client)
+			com := &challengeCommunicator{}
+
+			_, err := client.requestTrustedChallenge(context.Background(), com, time.Second)
+
+			assert.ErrorIs(t, err, IdentityMismatchError)
+			assert.Empty(t, com.sent, "nothing should be sent to an untrusted device")
+		})
+	}
+}
+
+func TestClient_requestTrustedChallenge_WithoutPinning(t *testing.T) {
+	com := &challengeCommunicator{err: communication.DecryptionError}
+
+	_, err := NewClient(nil).requestTrustedChallenge(context.Background(), com, time.Second)
+
+	assert.ErrorIs(t, err, communication.DecryptionError)
+	assert.NotErrorIs(t, err, IdentityMismatchError)
+}
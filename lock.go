@@ -5,25 +5,137 @@ import (
 	"fmt"
 	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"time"
 )
 
+// InvalidNameSuffixError will be returned if the name suffix of an action is too long (see CallNameSuffix)
+var InvalidNameSuffixError = fmt.Errorf("the given name suffix is too long (max %d bytes)", command.NameSuffixLength)
+
+// LockActionResult contains the outcome of a lock action. See PerformLockActionWithResult.
+type LockActionResult struct {
+	Action command.LockAction
+	// Duration is the time between sending the action and its completion.
+	Duration time.Duration
+
+	// States are the last states which were received after the action was sent. The following fields are
+	// taken from them. If the device has not sent any states, it is nil and the following fields are not set.
+	States command.StatesCommand
+	// Confirmed is true if the states were requested (see ReadStates) after the completion of the action.
+	Confirmed bool
+
+	// CompletionStatus is the completion status of the last lock action (reported by the states).
+	CompletionStatus command.LockActionCompletionStatus
+	LockState        command.LockState
+	DoorSensorState  command.DoorSensorState
+	// Trigger is the trigger of the last lock action (reported by the states).
+	Trigger command.Trigger
+
+	completed bool
+}
+
+// Completed returns true if the device has reported the completion of the action (status COMPLETE). It does not
+// depend on the states: see ConfirmedByStates.
+func (r *LockActionResult) Completed() bool {
+	return r.completed
+}
+
+// ConfirmedByStates returns true if the states report that the last lock action was successful.
+func (r *LockActionResult) ConfirmedByStates() bool {
+	return r.States != nil && r.CompletionStatus == command.LockActionCompletionStatusSuccess
+}
+
+func (r *LockActionResult) applyStates(states command.StatesCommand) {
+	r.States = states
+	r.CompletionStatus = states.LastLockActionCompletionStatus()
+	r.LockState = states.LockState()
+	r.DoorSensorState = states.DoorSensorState()
+	r.Trigger = states.LastLockActionTrigger()
+}
+
 // PerformLock will request the connected and paired nuki smart lock to lock.
-func (c *Client) PerformLock(ctx context.Context, appId command.ClientId) error {
-	return c.PerformLockAction(ctx, appId, command.LockActionLock)
+func (c *Client) PerformLock(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionLock, opts...)
 }
 
 // PerformUnlock will request the connected and paired nuki smart lock to unlock.
-func (c *Client) PerformUnlock(ctx context.Context, appId command.ClientId) error {
-	return c.PerformLockAction(ctx, appId, command.LockActionUnlock)
+func (c *Client) PerformUnlock(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionUnlock, opts...)
+}
+
+// PerformUnlatch will request the connected and paired nuki smart lock to unlatch (unlock and pull the latch).
+func (c *Client) PerformUnlatch(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionUnlatch, opts...)
+}
+
+// PerformLockNGo will request the connected and paired nuki smart lock to unlock and lock again after the
+// configured lock 'n' go timeout.
+func (c *Client) PerformLockNGo(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionLockAndGo, opts...)
+}
+
+// PerformLockNGoWithUnlatch will request the connected and paired nuki smart lock to unlatch and lock again after the
+// configured lock 'n' go timeout.
+func (c *Client) PerformLockNGoWithUnlatch(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionLockAndGoWithUnlatch, opts...)
+}
+
+// PerformFullLock will request the connected and paired nuki smart lock to lock with two turns.
+func (c *Client) PerformFullLock(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformLockAction(ctx, appId, command.LockActionFullLock, opts...)
+}
+
+// PerformFobAction will request the connected and paired nuki smart lock to perform the action which is configured
+// for the given fob action (1, 2 or 3) of the device.
+func (c *Client) PerformFobAction(ctx context.Context, appId command.ClientId, fobAction uint8, opts ...CallOption) error {
+	if fobAction < 1 || fobAction > 3 {
+		return fmt.Errorf("invalid fob action %d: expect 1, 2 or 3", fobAction)
+	}
+	return c.PerformLockAction(ctx, appId, command.LockActionFobAction1+command.LockAction(fobAction-1), opts...)
 }
 
 // PerformLockAction will request the connected and paired nuki smart lock to perform the given lock action.
-func (c *Client) PerformLockAction(ctx context.Context, appId command.ClientId, action command.LockAction) error {
-	if c.udioCom.GetDeviceType() != communication.DeviceTypeSmartLock {
-		return fmt.Errorf("unexpected device type: this operation is only available for smart lock")
+// Lock actions will be preferred over other queued operations. The flags and the name suffix of the action can be set
+// per call (see CallFlags and CallNameSuffix).
+func (c *Client) PerformLockAction(ctx context.Context, appId command.ClientId, action command.LockAction, opts ...CallOption) error {
+	_, err := c.PerformLockActionWithResult(ctx, appId, action, false, opts...)
+	return err
+}
+
+// PerformLockActionWithResult will request the connected and paired nuki smart lock to perform the given lock action
+// (see PerformLockAction) and return its result. The result is based on the states which are pushed by the device
+// while performing the action. If confirm is true, the states will be requested after the completion of the
+// action additionally. So the result reflects the final state of the device (for example the door is locked).
+func (c *Client) PerformLockActionWithResult(ctx context.Context, appId command.ClientId, action command.LockAction, confirm bool, opts ...CallOption) (*LockActionResult, error) {
+	ctx = withCallOptions(ctx, opts)
+	options := callOptionsFrom(ctx)
+
+	if c.GetDeviceType() != communication.DeviceTypeSmartLock {
+		return nil, fmt.Errorf("unexpected device type: this operation is only available for smart lock")
 	}
+	if err := options.checkNameSuffix(); err != nil {
+		return nil, err
+	}
+
+	result := &LockActionResult{Action: action}
+	start := time.Now()
 
-	return c.PerformAction(ctx, func(nonce []byte) command.Command {
-		return command.NewLockAction(action, uint32(appId), 0, nil, nonce)
+	err := c.performAction(ctx, priorityHigh, true, lockActionNotApplied(action), result.applyStates, func(nonce []byte) command.Command {
+		return command.NewLockAction(action, uint32(appId), options.flags, options.nameSuffix, nonce)
 	})
+	if err != nil {
+		return nil, err
+	}
+	result.completed = true
+	result.Duration = time.Since(start)
+
+	if confirm {
+		states, err := c.ReadStates(ctx)
+		if err != nil {
+			return result, fmt.Errorf("unable to confirm the result of the lock action: %w", err)
+		}
+		result.applyStates(states)
+		result.Confirmed = true
+	}
+
+	return result, nil
 }
@@ -0,0 +1,155 @@
+package nuki
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+)
+
+func TestClient_PerformLockActionWithResult(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, []command.Command{
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusAccepted)}),
+		testStates(command.LockStateSmartLockLocking, command.LockActionCompletionStatusSuccess),
+		testStates(command.LockStateLocked, command.LockActionCompletionStatusSuccess),
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+	})
+	client := connectedTestClient(com)
+
+	result, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionLock, false)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Completed())
+	assert.True(t, result.ConfirmedByStates())
+	assert.False(t, result.Confirmed)
+	assert.Equal(t, command.LockActionLock, result.Action)
+	assert.Equal(t, command.LockStateLocked, result.LockState)
+	assert.Equal(t, command.DoorSensorStateDoorClosed, result.DoorSensorState)
+	assert.Equal(t, command.TriggerSystem, result.Trigger)
+}
+
+func TestClient_PerformLockActionWithResult_Confirm(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, completed())
+	client := connectedTestClient(com)
+
+	result, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionLock, true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Confirmed)
+	assert.True(t, result.Completed())
+	assert.True(t, result.ConfirmedByStates())
+	assert.Equal(t, command.LockStateLocked, result.LockState)
+}
+
+func TestClient_PerformLockActionWithResult_NoStates(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, completed())
+	client := connectedTestClient(com)
+
+	result, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionLock, false)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.States)
+	assert.True(t, result.Completed())
+	assert.False(t, result.ConfirmedByStates())
+}
+
+func TestClient_PerformLockActionWithResult_MotorBlocked(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, []command.Command{
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusAccepted)}),
+		testStates(command.LockStateSmartLockMotorBlocked, command.LockActionCompletionStatusMotorBlocked),
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+	})
+	client := connectedTestClient(com)
+
+	result, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionLock, false)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Completed())
+	assert.False(t, result.ConfirmedByStates())
+	assert.Equal(t, command.LockActionCompletionStatusMotorBlocked, result.CompletionStatus)
+	assert.Equal(t, "motorBlocked", result.CompletionStatus.String())
+}
+
+func TestClient_PerformLockAction_ConvenienceMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		perform func(client *Client) error
+		action  command.LockAction
+	}{
+		{"unlatch", func(c *Client) error { return c.PerformUnlatch(context.Background(), 13) }, command.LockActionUnlatch},
+		{"lock 'n' go", func(c *Client) error { return c.PerformLockNGo(context.Background(), 13) }, command.LockActionLockAndGo},
+		{"lock 'n' go with unlatch", func(c *Client) error { return c.PerformLockNGoWithUnlatch(context.Background(), 13) }, command.LockActionLockAndGoWithUnlatch},
+		{"full lock", func(c *Client) error { return c.PerformFullLock(context.Background(), 13) }, command.LockActionFullLock},
+		{"fob action 1", func(c *Client) error { return c.PerformFobAction(context.Background(), 13, 1) }, command.LockActionFobAction1},
+		{"fob action 3", func(c *Client) error { return c.PerformFobAction(context.Background(), 13, 3) }, command.LockActionFobAction3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			com := lockDevice(command.LockStateLocked, completed())
+
+			err := tt.perform(connectedTestClient(com))
+
+			assert.NoError(t, err)
+			actions := com.sentOf(command.IdLockAction)
+			if assert.Len(t, actions, 1) {
+				assert.Equal(t, byte(tt.action), actions[0].Payload()[0])
+			}
+		})
+	}
+}
+
+func TestClient_PerformFobAction_Invalid(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, completed())
+
+	for _, fobAction := range []uint8{0, 4} {
+		err := connectedTestClient(com).PerformFobAction(context.Background(), 13, fobAction)
+		assert.Error(t, err)
+	}
+	assert.Empty(t, com.sent)
+}
+
+func TestClient_PerformLockAction_NameSuffixTooLong(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, completed())
+
+	err := connectedTestClient(com).PerformUnlock(context.Background(), 13, CallNameSuffix("a name suffix which is too long"))
+
+	assert.ErrorIs(t, err, InvalidNameSuffixError)
+	assert.Empty(t, com.sent)
+
+	err = connectedTestClient(com).PerformUnlock(context.Background(), 13, CallNameSuffix("exactly twenty bytes"))
+	assert.NoError(t, err)
+}
+
+func TestClient_PerformLockAction_CompletedWithoutAcceptance(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, []command.Command{
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+	})
+
+	err := connectedTestClient(com).PerformLockAction(context.Background(), 13, command.LockActionUnlock)
+
+	assert.NoError(t, err)
+}
+
+func TestClient_PerformLockAction_SkipPendingCompletion(t *testing.T) {
+	com := lockDevice(command.LockStateLocked,
+		//the completion of the first action is not received in time
+		[]command.Command{command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusAccepted)})},
+		[]command.Command{
+			command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+			command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusAccepted)}),
+			testStates(command.LockStateSmartLockUnlocked, command.LockActionCompletionStatusSuccess),
+			command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+		},
+	)
+	client := connectedTestClient(com)
+
+	_, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionUnlock, false)
+	assert.Error(t, err)
+
+	result, err := client.PerformLockActionWithResult(context.Background(), 13, command.LockActionUnlock, false)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, result.States, "the pending completion of the first action must be skipped") {
+		assert.Equal(t, command.LockStateSmartLockUnlocked, result.LockState)
+	}
+}
@@ -3,119 +3,233 @@ package nuki
 import (
 	"context"
 	"fmt"
+	"github.com/go-ble/ble"
+	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"time"
 )
 
+// LogEntryStreamChunkSize is the maximum count of log entries which will be requested at once by ReadLogEntryStream.
+var LogEntryStreamChunkSize = uint16(32)
+
 // ReadLogEntriesCount will return the count of persisting logs.
-func (c *Client) ReadLogEntriesCount(ctx context.Context, pin string) (command.LogEntryCountCommand, error) {
-	parsedPin, err := c.checkPreconditionAndParsePin(pin)
+func (c *Client) ReadLogEntriesCount(ctx context.Context, pin string, opts ...CallOption) (command.LogEntryCountCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.udioCom.Send(command.NewRequest(command.IdChallenge))
-	if err != nil {
-		return nil, fmt.Errorf("unable to send request for challenge: %w", err)
-	}
+	var result command.LogEntryCountCommand
 
-	challenge, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("error while waiting for challenge: %w", err)
-	}
-	err = c.udioCom.Send(command.NewRequestLogEntriesCountCommand(
-		parsedPin,
-		challenge.AsChallengeCommand().Nonce(),
-	))
-	if err != nil {
-		return nil, fmt.Errorf("unable to send request for log count: %w", err)
-	}
+	err = c.exchange(ctx, priorityNormal, true, func(com communication.Communicator, timeout time.Duration) error {
+		nonce, err := c.requestTrustedChallenge(ctx, com, timeout)
+		if err != nil {
+			return err
+		}
 
-	logEntryCount, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdLogEntryCount, c.responseTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("error while waiting for log entry count: %w", err)
-	}
+		err = com.Send(command.NewRequestLogEntriesCountCommand(parsedPin, nonce))
+		if err != nil {
+			return fmt.Errorf("unable to send request for log count: %w", err)
+		}
 
-	status, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdStatus, c.responseTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("error while waiting for status: %w", err)
-	}
+		logEntryCount, err := com.WaitForSpecificResponse(ctx, command.IdLogEntryCount, timeout)
+		if err != nil {
+			return fmt.Errorf("error while waiting for log entry count: %w", err)
+		}
+
+		status, err := com.WaitForSpecificResponse(ctx, command.IdStatus, timeout)
+		if err != nil {
+			return fmt.Errorf("error while waiting for status: %w", err)
+		}
 
-	if !status.AsStatusCommand().IsComplete() {
-		return nil, fmt.Errorf("unexpected status: expect 0x%02x got 0x%02x", command.CompletionStatusComplete, status.AsStatusCommand().Status())
+		statusCommand, err := status.ParseStatusCommand()
+		if err != nil {
+			return fmt.Errorf("invalid status: %w", err)
+		}
+		if !statusCommand.IsComplete() {
+			return fmt.Errorf("unexpected status: expect %s got %s", command.CompletionStatusComplete, statusCommand.Status())
+		}
+
+		result, err = logEntryCount.ParseLogEntriesCountCommand()
+		if err != nil {
+			return fmt.Errorf("invalid log entry count: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return logEntryCount.AsLogEntriesCountCommand(), nil
+	return result, nil
 }
 
 // ReadLogEntryStream will start consume the persisted logs from the device. While the callback function will be called
 // foreach received log entry. This function is blocking which mean it will return after the log receiving is done.
-func (c *Client) ReadLogEntryStream(ctx context.Context, start uint32, count uint16, order command.LogSortOrder, pin string, clb func(command.LogEntryCommand)) error {
-	parsedPin, err := c.checkPreconditionAndParsePin(pin)
+// The log entries will be requested in chunks (see LogEntryStreamChunkSize). Between two chunks other queued operations
+// (such as lock actions) will be preferred. The callback is called after a chunk was received completely (and not while
+// the communication is exclusive): so the callback can use the client (for example to read the states). If the context
+// is done while a chunk is transferred, the remaining entries of this chunk will be consumed (but not passed to the
+// callback) before returning. So the communication stays in sync.
+func (c *Client) ReadLogEntryStream(ctx context.Context, start uint32, count uint16, order command.LogSortOrder, pin string, clb func(command.LogEntryCommand), opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
+
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
 		return err
 	}
 
-	err = c.udioCom.Send(command.NewRequest(command.IdChallenge))
+	remaining := count
+	resumed := false
+	for remaining > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunkSize := remaining
+		if chunkSize > LogEntryStreamChunkSize {
+			chunkSize = LogEntryStreamChunkSize
+		}
+
+		entries, bleClient, err := c.readQueuedLogEntryChunk(ctx, start, chunkSize, order, parsedPin)
+
+		done := false
+		for _, entry := range entries {
+			clb(entry)
+
+			//the next entry to request (in case of the following chunk or a resumption)
+			if order == command.LogSortOrderDescending {
+				done = entry.Index() <= 1
+				start = entry.Index() - 1
+			} else {
+				start = entry.Index() + 1
+			}
+		}
+		received := uint16(len(entries))
+		remaining -= received
+
+		if err != nil {
+			if bleClient == nil || (resumed && received == 0) {
+				return err
+			}
+			if !c.recoverConnection(ctx, bleClient) {
+				return err
+			}
+			resumed = true
+
+			if logger.Info != nil {
+				logger.Info.Printf("[CONNECTION] Resume the interrupted log entry stream after reconnection.")
+			}
+			continue
+		}
+		resumed = false
+
+		if done || received < chunkSize {
+			break //the device has no more entries
+		}
+	}
+
+	return nil
+}
+
+// readQueuedLogEntryChunk will read one chunk of log entries (see readLogEntryChunk) while the communication is
+// exclusive. Returns the received entries and the connection which was used (nil if the chunk was not requested).
+func (c *Client) readQueuedLogEntryChunk(ctx context.Context, start uint32, count uint16, order command.LogSortOrder, pin command.Pin) ([]command.LogEntryCommand, ble.Client, error) {
+	if err := c.queue.acquire(ctx, priorityLow); err != nil {
+		return nil, nil, err
+	}
+	defer c.queue.release()
+
+	com, timeout, err := c.checkPrecondition(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to send request for challenge: %w", err)
+		return nil, nil, err
 	}
 
-	challenge, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
+	c.mu.RLock()
+	bleClient := c.client
+	c.mu.RUnlock()
+
+	entries := make([]command.LogEntryCommand, 0, count)
+	err = c.readLogEntryChunk(ctx, com, timeout, start, count, order, pin, func(entry command.LogEntryCommand) {
+		entries = append(entries, entry)
+	})
+	return entries, bleClient, err
+}
+
+func (c *Client) readLogEntryChunk(ctx context.Context, com communication.Communicator, timeout time.Duration, start uint32, count uint16, order command.LogSortOrder, pin command.Pin, clb func(command.LogEntryCommand)) error {
+	nonce, err := c.requestTrustedChallenge(ctx, com, timeout)
 	if err != nil {
-		return fmt.Errorf("error while waiting for challenge: %w", err)
+		return err
 	}
-	err = c.udioCom.Send(command.NewRequestLogEntriesCommand(
-		start,
-		count,
-		order,
-		parsedPin,
-		challenge.AsChallengeCommand().Nonce(),
-	))
+
+	err = com.Send(command.NewRequestLogEntriesCommand(start, count, order, pin, nonce))
 	if err != nil {
 		return fmt.Errorf("unable to send request for log entries: %w", err)
 	}
 
 	for {
-		resp, err := c.udioCom.WaitForResponse(ctx, c.responseTimeout)
+		resp, err := com.WaitForResponse(ctx, timeout)
 		if err != nil {
+			drainLogEntryChunk(com, timeout)
 			return fmt.Errorf("error while waiting for log entry: %w", err)
 		}
 		if resp.Is(command.IdLogEntry) {
-			clb(resp.AsLogEntryCommand())
+			logEntry, err := resp.ParseLogEntryCommand()
+			if err != nil {
+				drainLogEntryChunk(com, timeout)
+				return fmt.Errorf("invalid log entry: %w", err)
+			}
+			clb(logEntry)
 		} else if resp.Is(command.IdStatus) {
-			break //we are done
+			return nil //we are done
+		} else if resp.Is(command.IdStates) {
+			continue //pushed by the device (for example while the motor is moving)
 		} else {
+			drainLogEntryChunk(com, timeout)
 			return fmt.Errorf("unexpected response type")
 		}
 	}
+}
 
-	return nil
+// drainLogEntryChunk will consume all remaining log entries (and pushed states) of an already requested chunk. So
+// that these entries will not be misinterpreted as response of following requests.
+func drainLogEntryChunk(com communication.Communicator, timeout time.Duration) {
+	for {
+		resp, err := com.WaitForResponse(context.Background(), timeout)
+		if err != nil || !(resp.Is(command.IdLogEntry) || resp.Is(command.IdStates)) {
+			return
+		}
+	}
 }
 
 // ReadLogEntries will return the persisted log entries from the device. All logentries will be saved in memory! For a huge
 // load of log entries consider the usage of ReadLogEntryStream instead.
-func (c *Client) ReadLogEntries(ctx context.Context, start uint32, count uint16, order command.LogSortOrder, pin string) ([]command.LogEntryCommand, error) {
+func (c *Client) ReadLogEntries(ctx context.Context, start uint32, count uint16, order command.LogSortOrder, pin string, opts ...CallOption) ([]command.LogEntryCommand, error) {
 	result := make([]command.LogEntryCommand, 0, count)
 	err := c.ReadLogEntryStream(ctx, start, count, order, pin, func(logEntry command.LogEntryCommand) {
 		result = append(result, logEntry)
-	})
+	}, opts...)
 
 	return result, err
 }
 
 // EnableLogging will enable the logging on the connected nuki device.
-func (c *Client) EnableLogging(ctx context.Context, pin string) error {
-	return c.SetLogging(ctx, pin, true)
+func (c *Client) EnableLogging(ctx context.Context, pin string, opts ...CallOption) error {
+	return c.SetLogging(ctx, pin, true, opts...)
 }
 
 // DisableLogging will disable the logging on the connected nuki device.
-func (c *Client) DisableLogging(ctx context.Context, pin string) error {
-	return c.SetLogging(ctx, pin, false)
+func (c *Client) DisableLogging(ctx context.Context, pin string, opts ...CallOption) error {
+	return c.SetLogging(ctx, pin, false, opts...)
 }
 
 // SetLogging will set the logging on the connected nuki device.
-func (c *Client) SetLogging(ctx context.Context, pin string, enable bool) error {
-	parsedPin, err := c.checkPreconditionAndParsePin(pin)
+func (c *Client) SetLogging(ctx context.Context, pin string, enable bool, opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
+
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,194 @@
+package nuki
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/go-ble/ble"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"testing"
+	"time"
+)
+
+// connectedBleClient is a ble.Client which is only used as marker for an established connection.
+type connectedBleClient struct {
+	ble.Client
+}
+
+// logDevice simulates the user-specific data io of a device which holds a lot of log entries. All responses
+// are encrypted and will be decrypted while receiving (like the real communicator does).
+type logDevice struct {
+	communication.Communicator
+
+	decrypt   func(command.Command) command.Command
+	challenge command.Command
+	states    command.Command
+	status    command.Command
+	entries   []command.Command
+	pending   []command.Command
+	// pushStates will push the states after each log entry (like the device does while the motor is moving)
+	pushStates bool
+}
+
+func logDeviceKeys() (privateKey, nukiPubKey []byte) {
+	privateKey, _ = hex.DecodeString("8CAA54672307BFFDF5EA183FC607158D2011D008ECA6A1088614FF0853A5AA07")
+	nukiPubKey, _ = hex.DecodeString("2FE57DA347CD62431528DAAC5FBB290730FFF684AFC4CFC2ED90995F58CB3B74")
+	return
+}
+
+func newLogDevice(entryCount int, decrypt func(command.Command) command.Command) *logDevice {
+	sharedKey := command.NewSharedKey(logDeviceKeys())
+	encrypt := func(cmd command.Command) command.Command {
+		return command.EncryptCommandWithSharedKey(2, sharedKey, cmd)
+	}
+
+	device := &logDevice{
+		decrypt:   decrypt,
+		challenge: encrypt(command.NewCommand(command.IdChallenge, make([]byte, 32))),
+		states:    encrypt(testStates(command.LockStateLocked, command.LockActionCompletionStatusSuccess)),
+		status:    encrypt(command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)})),
+		entries:   make([]command.Command, entryCount),
+	}
+	for i := range device.entries {
+		payload := make([]byte, 52)
+		binary.LittleEndian.PutUint32(payload[0:4], uint32(i+1))
+		binary.LittleEndian.PutUint16(payload[4:6], 2022)
+		payload[6], payload[7] = 6, 1
+		payload[47] = byte(command.LoggingTypeLockAction)
+		device.entries[i] = encrypt(command.NewCommand(command.IdLogEntry, payload))
+	}
+
+	return device
+}
+
+func (d *logDevice) Send(cmd command.Command) error {
+	d.pending = d.pending[:0]
+
+	switch {
+	case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdStates):
+		d.pending = append(d.pending, d.states)
+	case cmd.Is(command.IdRequestData):
+		d.pending = append(d.pending, d.challenge)
+	case cmd.Is(command.IdRequestLogEntries):
+		start := int(binary.LittleEndian.Uint32(cmd.Payload()[0:4]))
+		count := int(binary.LittleEndian.Uint16(cmd.Payload()[4:6]))
+		for i := start; i < start+count && i <= len(d.entries); i++ {
+			d.pending = append(d.pending, d.entries[i-1])
+			if d.pushStates {
+				d.pending = append(d.pending, d.states)
+			}
+		}
+		d.pending = append(d.pending, d.status)
+	}
+	return nil
+}
+
+func (d *logDevice) WaitForResponse(context.Context, time.Duration) (command.Command, error) {
+	if len(d.pending) == 0 {
+		return nil, communication.TimeoutErr
+	}
+
+	next := d.pending[0]
+	d.pending = d.pending[1:]
+	return d.decrypt(next), nil
+}
+
+func (d *logDevice) WaitForSpecificResponse(ctx context.Context, expectedType command.Id, timeout time.Duration) (command.Command, error) {
+	for {
+		cmd, err := d.WaitForResponse(ctx, timeout)
+		if err != nil || cmd.Is(expectedType) {
+			return cmd, err
+		}
+	}
+}
+
+func TestClient_ReadLogEntryStream_ClientInCallback(t *testing.T) {
+	defer func(chunkSize uint16) { LogEntryStreamChunkSize = chunkSize }(LogEntryStreamChunkSize)
+	LogEntryStreamChunkSize = 2
+
+	sharedKey := command.NewSharedKey(logDeviceKeys())
+	client := connectedTestClient(newLogDevice(5, func(cmd command.Command) command.Command {
+		_, decrypted := command.DecryptCommandWithSharedKey(cmd, sharedKey)
+		return decrypted
+	}))
+
+	done := make(chan error, 1)
+	received := 0
+	go func() {
+		done <- client.ReadLogEntryStream(context.Background(), 1, 5, command.LogSortOrderAscending, "1234", func(command.LogEntryCommand) {
+			received++
+
+			//must not dead lock: the queue is not held while the callback is called
+			_, err := client.ReadStates(context.Background())
+			assert.NoError(t, err)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Equal(t, 5, received)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client can not be used inside the callback")
+	}
+}
+
+func TestClient_ReadLogEntries_PushedStates(t *testing.T) {
+	sharedKey := command.NewSharedKey(logDeviceKeys())
+	device := newLogDevice(3, func(cmd command.Command) command.Command {
+		_, decrypted := command.DecryptCommandWithSharedKey(cmd, sharedKey)
+		return decrypted
+	})
+	device.pushStates = true
+	client := connectedTestClient(device)
+
+	entries, err := client.ReadLogEntries(context.Background(), 1, 3, command.LogSortOrderAscending, "1234")
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Empty(t, device.pending, "the whole chunk should be consumed")
+}
+
+func benchmarkReadLogEntryStream(b *testing.B, entryCount int, decrypt func(command.Command) command.Command) {
+	defer func(info logger.Logger) { logger.Info = info }(logger.Info)
+	logger.Info = nil
+
+	client := NewClient(nil)
+	client.client = connectedBleClient{}
+	client.udioCom = newLogDevice(entryCount, decrypt)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		received := 0
+		err := client.ReadLogEntryStream(context.Background(), 1, uint16(entryCount), command.LogSortOrderAscending, "1234", func(command.LogEntryCommand) {
+			received++
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if received != entryCount {
+			b.Fatalf("received %d of %d entries", received, entryCount)
+		}
+	}
+}
+
+func BenchmarkClient_ReadLogEntryStream(b *testing.B) {
+	privateKey, nukiPubKey := logDeviceKeys()
+
+	b.Run("precomputed shared key", func(b *testing.B) {
+		sharedKey := command.NewSharedKey(privateKey, nukiPubKey)
+		benchmarkReadLogEntryStream(b, 5000, func(cmd command.Command) command.Command {
+			_, decrypted := command.DecryptCommandWithSharedKey(cmd, sharedKey)
+			return decrypted
+		})
+	})
+	b.Run("shared key per message", func(b *testing.B) {
+		benchmarkReadLogEntryStream(b, 5000, func(cmd command.Command) command.Command {
+			_, decrypted := command.DecryptCommand(cmd, privateKey, nukiPubKey)
+			return decrypted
+		})
+	})
+}
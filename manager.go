@@ -0,0 +1,259 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ble/ble"
+	"github.com/kevinburke/nacl"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UnknownDeviceError will be returned if the given device is not registered
+var UnknownDeviceError = fmt.Errorf("the device is not registered")
+
+// DeviceAlreadyRegisteredError will be returned if a device with the same id is already registered
+var DeviceAlreadyRegisteredError = fmt.Errorf("a device with the same id is already registered")
+
+// DeviceTypeMismatchError will be returned if the connected device has another type than the registered one
+var DeviceTypeMismatchError = fmt.Errorf("the connected device has an unexpected type")
+
+// UnsupportedDeviceTypeError will be returned if a device with an unknown type should be registered
+var UnsupportedDeviceTypeError = fmt.Errorf("the device type is not supported")
+
+type DeviceId string
+
+// DeviceConfig contains all information which are necessary to communicate with one paired device.
+type DeviceConfig struct {
+	Id      DeviceId
+	Name    string
+	Address ble.Addr
+	// Type is the expected type of the device. If it is DeviceTypeUnknown, the type will not be checked.
+	Type communication.DeviceType
+
+	AuthId        command.AuthorizationId
+	PrivateKey    nacl.Key
+	PublicKey     nacl.Key
+	NukiPublicKey []byte
+
+	// IdleTimeout is the duration after the unused connection will be disconnected. If zero, the DefaultIdleTimeout is used.
+	IdleTimeout time.Duration
+}
+
+// DeviceHealth contains the last known health information of a device.
+type DeviceHealth struct {
+	Connected   bool
+	LastSeen    time.Time
+	LastError   error
+	LastErrorAt time.Time
+
+	// BatteryKnown is true if at least one states was received from the device. The battery information is taken
+	// from the last received states (pushed by the device or read by ReadStates): they are not requested on connect.
+	// So they can be outdated, see BatteryUpdatedAt.
+	BatteryKnown     bool
+	BatteryUpdatedAt time.Time
+	BatteryCritical  bool
+	BatteryCharging  bool
+	// BatteryPercentage is only available for smart locks
+	BatteryPercentage uint8
+}
+
+type managedDevice struct {
+	config  DeviceConfig
+	session *Session
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	health DeviceHealth
+}
+
+// Manager holds a registry of devices and routes the calls to them. All devices of one manager share the same
+// bluetooth adapter: the count of simultaneous connections will be limited.
+type Manager struct {
+	bleDevice ble.Device
+	limiter   *ConnectionLimiter
+
+	mu      sync.RWMutex
+	devices map[DeviceId]*managedDevice
+}
+
+// NewManager creates a new manager for the given bluetooth adapter which holds at most the given count of
+// simultaneous connections (at least one, see NewConnectionLimiter).
+func NewManager(bleDevice ble.Device, maxConnections int) *Manager {
+	return &Manager{
+		bleDevice: bleDevice,
+		limiter:   NewConnectionLimiter(maxConnections),
+		devices:   map[DeviceId]*managedDevice{},
+	}
+}
+
+// Register adds the given device to the registry. The connection will not be established until the device is used.
+func (m *Manager) Register(config DeviceConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch config.Type {
+	case communication.DeviceTypeUnknown, communication.DeviceTypeSmartLock, communication.DeviceTypeOpener:
+	default:
+		return fmt.Errorf("%w: 0x%02x", UnsupportedDeviceTypeError, uint8(config.Type))
+	}
+	if _, ok := m.devices[config.Id]; ok {
+		return DeviceAlreadyRegisteredError
+	}
+
+	idleTimeout := config.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	device := &managedDevice{
+		config: config,
+		session: NewSession(m.bleDevice, config.Address, config.PrivateKey, config.PublicKey, config.NukiPublicKey, config.AuthId).
+			WithIdleTimeout(idleTimeout).
+			WithConnectionLimiter(m.limiter),
+		cancel: cancel,
+	}
+	device.watch(ctx)
+
+	m.devices[config.Id] = device
+	return nil
+}
+
+// Unregister removes the given device from the registry and closes its connection.
+func (m *Manager) Unregister(id DeviceId) error {
+	m.mu.Lock()
+	device, ok := m.devices[id]
+	delete(m.devices, id)
+	m.mu.Unlock()
+
+	if !ok {
+		return UnknownDeviceError
+	}
+
+	device.cancel()
+	return device.session.Close()
+}
+
+// Devices returns the configurations of all registered devices (ordered by id).
+func (m *Manager) Devices() []DeviceConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]DeviceConfig, 0, len(m.devices))
+	for _, device := range m.devices {
+		result = append(result, device.config)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+
+	return result
+}
+
+// Do will call the given function with a connected and authenticated client of the given device. The connection
+// will be established if necessary. The outcome will be recorded in the health of the device.
+func (m *Manager) Do(ctx context.Context, id DeviceId, fn func(client *Client) error) error {
+	device, err := m.device(id)
+	if err != nil {
+		return err
+	}
+
+	err = device.session.Do(ctx, func(client *Client) error {
+		if device.config.Type != communication.DeviceTypeUnknown && client.GetDeviceType() != device.config.Type {
+			return DeviceTypeMismatchError
+		}
+
+		return fn(client)
+	})
+	device.record(err)
+
+	return err
+}
+
+// Health returns the last known health information of the given device.
+func (m *Manager) Health(id DeviceId) (DeviceHealth, error) {
+	device, err := m.device(id)
+	if err != nil {
+		return DeviceHealth{}, err
+	}
+
+	device.mu.Lock()
+	defer device.mu.Unlock()
+
+	health := device.health
+	health.Connected = device.session.IsConnected()
+	return health, nil
+}
+
+// Close will close the connections of all registered devices.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	errors := make([]error, 0)
+	for _, device := range m.devices {
+		device.cancel()
+		if err := device.session.Close(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	m.devices = map[DeviceId]*managedDevice{}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("error while closing devices: [%v]", errors)
+	}
+
+	return nil
+}
+
+func (m *Manager) device(id DeviceId) (*managedDevice, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	device, ok := m.devices[id]
+	if !ok {
+		return nil, UnknownDeviceError
+	}
+	return device, nil
+}
+
+// watch will update the health information by all events of the device.
+func (d *managedDevice) watch(ctx context.Context) {
+	events := d.session.Subscribe(ctx)
+
+	go func() {
+		for event := range events {
+			d.mu.Lock()
+			if event.Type != EventTypeConnection {
+				d.health.LastSeen = event.ReceivedAt
+			}
+
+			if states := event.States(); states != nil {
+				d.health.BatteryKnown = true
+				d.health.BatteryUpdatedAt = event.ReceivedAt
+				if smartLockStates := states.AsSmartLockStates(); smartLockStates != nil {
+					d.health.BatteryCritical, d.health.BatteryCharging, d.health.BatteryPercentage = smartLockStates.CriticalBatteryState()
+				} else if openerStates := states.AsOpenerStates(); openerStates != nil {
+					d.health.BatteryCritical = openerStates.CriticalBatteryState()
+				}
+			}
+			d.mu.Unlock()
+		}
+	}()
+}
+
+func (d *managedDevice) record(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err != nil {
+		d.health.LastError = err
+		d.health.LastErrorAt = time.Now()
+	} else {
+		d.health.LastSeen = time.Now()
+	}
+}
@@ -0,0 +1,107 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ble/ble"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func testDeviceConfig(id DeviceId, deviceType communication.DeviceType) DeviceConfig {
+	return DeviceConfig{
+		Id:      id,
+		Name:    string(id),
+		Address: ble.NewAddr("00:00:00:00:00:01"),
+		Type:    deviceType,
+		AuthId:  1,
+	}
+}
+
+// registerFake registers the given device whose session will "connect" to the given communicator.
+func registerFake(t *testing.T, manager *Manager, config DeviceConfig, com communication.Communicator) {
+	assert.NoError(t, manager.Register(config))
+
+	device, err := manager.device(config.Id)
+	if assert.NoError(t, err) {
+		fakeEstablish(device.session, com)
+	}
+}
+
+func TestManager_RegisterUnregister(t *testing.T) {
+	toTest := NewManager(nil, 1)
+	defer toTest.Close()
+
+	assert.NoError(t, toTest.Register(testDeviceConfig("b", communication.DeviceTypeSmartLock)))
+	assert.NoError(t, toTest.Register(testDeviceConfig("a", communication.DeviceTypeUnknown)))
+	assert.ErrorIs(t, toTest.Register(testDeviceConfig("a", communication.DeviceTypeOpener)), DeviceAlreadyRegisteredError)
+
+	devices := toTest.Devices()
+	if assert.Len(t, devices, 2) {
+		assert.Equal(t, DeviceId("a"), devices[0].Id)
+		assert.Equal(t, DeviceId("b"), devices[1].Id)
+	}
+
+	assert.NoError(t, toTest.Unregister("a"))
+	assert.ErrorIs(t, toTest.Unregister("a"), UnknownDeviceError)
+	assert.Len(t, toTest.Devices(), 1)
+
+	_, err := toTest.Health("a")
+	assert.ErrorIs(t, err, UnknownDeviceError)
+	assert.ErrorIs(t, toTest.Do(context.Background(), "a", func(*Client) error { return nil }), UnknownDeviceError)
+}
+
+func TestManager_Register_InvalidType(t *testing.T) {
+	toTest := NewManager(nil, 1)
+	defer toTest.Close()
+
+	assert.ErrorIs(t, toTest.Register(testDeviceConfig("a", communication.DeviceType(0x05))), UnsupportedDeviceTypeError)
+	assert.Empty(t, toTest.Devices())
+}
+
+func TestManager_Do_TypeMismatch(t *testing.T) {
+	toTest := NewManager(nil, 1)
+	defer toTest.Close()
+	registerFake(t, toTest, testDeviceConfig("opener", communication.DeviceTypeOpener), lockDevice(command.LockStateLocked))
+
+	called := false
+	err := toTest.Do(context.Background(), "opener", func(*Client) error {
+		called = true
+		return nil
+	})
+
+	assert.ErrorIs(t, err, DeviceTypeMismatchError)
+	assert.False(t, called)
+}
+
+func TestManager_Health(t *testing.T) {
+	toTest := NewManager(nil, 1)
+	defer toTest.Close()
+	registerFake(t, toTest, testDeviceConfig("lock", communication.DeviceTypeSmartLock), lockDevice(command.LockStateLocked))
+
+	health, err := toTest.Health("lock")
+	assert.NoError(t, err)
+	assert.False(t, health.Connected)
+	assert.False(t, health.BatteryKnown)
+
+	doErr := fmt.Errorf("something went wrong")
+	assert.ErrorIs(t, toTest.Do(context.Background(), "lock", func(*Client) error { return doErr }), doErr)
+
+	health, err = toTest.Health("lock")
+	assert.NoError(t, err)
+	assert.True(t, health.Connected)
+	assert.ErrorIs(t, health.LastError, doErr)
+	assert.False(t, health.LastErrorAt.IsZero())
+
+	//battery information of pushed states
+	device, _ := toTest.device("lock")
+	device.session.client.events.publish(Event{Type: EventTypeStates, ReceivedAt: time.Now(), Command: testStates(command.LockStateLocked, command.LockActionCompletionStatusSuccess)})
+
+	assert.Eventually(t, func() bool {
+		health, _ := toTest.Health("lock")
+		return health.BatteryKnown && !health.BatteryUpdatedAt.IsZero()
+	}, time.Second, 5*time.Millisecond)
+}
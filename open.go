@@ -8,17 +8,25 @@ import (
 )
 
 // PerformOpen will trigger the electric strike actuation to open the door and return the result.
-func (c *Client) PerformOpen(ctx context.Context, appId command.ClientId) error {
-	return c.PerformOpenAction(ctx, appId, command.OpenActionElectricStrikeActuation)
+func (c *Client) PerformOpen(ctx context.Context, appId command.ClientId, opts ...CallOption) error {
+	return c.PerformOpenAction(ctx, appId, command.OpenActionElectricStrikeActuation, opts...)
 }
 
 // PerformOpenAction will request the connected and paired nuki opener to perform the given open action.
-func (c *Client) PerformOpenAction(ctx context.Context, appId command.ClientId, action command.OpenAction) error {
-	if c.udioCom.GetDeviceType() != communication.DeviceTypeOpener {
+// Open actions will be preferred over other queued operations. The flags and the name suffix of the action can be set
+// per call (see CallFlags and CallNameSuffix).
+func (c *Client) PerformOpenAction(ctx context.Context, appId command.ClientId, action command.OpenAction, opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
+	options := callOptionsFrom(ctx)
+
+	if c.GetDeviceType() != communication.DeviceTypeOpener {
 		return fmt.Errorf("unexpected device type: this operation is only available for opener")
 	}
+	if err := options.checkNameSuffix(); err != nil {
+		return err
+	}
 
-	return c.PerformAction(ctx, func(nonce []byte) command.Command {
-		return command.NewOpenAction(action, uint32(appId), 0, nil, nonce)
+	return c.performAction(ctx, priorityHigh, true, openActionNotApplied(action), nil, func(nonce []byte) command.Command {
+		return command.NewOpenAction(action, uint32(appId), options.flags, options.nameSuffix, nonce)
 	})
 }
@@ -0,0 +1,112 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/tarent/go-nuki/communication/command"
+	"time"
+)
+
+// CallOption tunes a single operation of the client (for example the timeout of one lock action). Options which
+// are not relevant for an operation will be ignored (for example the flags for reading the states).
+type CallOption func(o *callOptions)
+
+// PinSource provides the security pin when it is needed (for example from a secret store).
+type PinSource func(ctx context.Context) (string, error)
+
+type callOptions struct {
+	timeout     time.Duration
+	retryPolicy *RetryPolicy
+	noRetry     bool
+	nameSuffix  *string
+	flags       uint8
+	pinSource   PinSource
+}
+
+type callOptionsKey struct{}
+
+// CallTimeout sets the timeout which is used for each response waiting of the operation (instead of the timeout of
+// the client, see Client.WithTimeout).
+func CallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// CallRetry sets the retry policy of the operation (instead of the policy of the client, see Client.WithRetry).
+func CallRetry(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retryPolicy = &policy
+		o.noRetry = false
+	}
+}
+
+// CallWithoutRetry disables the retry for the operation even if the client has a retry policy.
+func CallWithoutRetry() CallOption {
+	return func(o *callOptions) {
+		o.retryPolicy = nil
+		o.noRetry = true
+	}
+}
+
+// CallNameSuffix sets the name suffix of a lock or open action. The device adds it to the name of the authorization
+// in its log entries. So services or users which share one authorization can be distinguished. The suffix must not be
+// longer than command.NameSuffixLength bytes (otherwise the action fails with InvalidNameSuffixError).
+func CallNameSuffix(nameSuffix string) CallOption {
+	return func(o *callOptions) {
+		o.nameSuffix = &nameSuffix
+	}
+}
+
+// CallFlags adds the given flags to a lock or open action (for example command.LockActionFlagForce).
+func CallFlags(flags uint8) CallOption {
+	return func(o *callOptions) {
+		o.flags |= flags
+	}
+}
+
+// CallForce forces the execution of a lock or open action (see command.LockActionFlagForce).
+func CallForce() CallOption {
+	return CallFlags(command.LockActionFlagForce)
+}
+
+// CallAutoUnlock marks a lock action as auto unlock (see command.LockActionFlagAutoUnlock).
+func CallAutoUnlock() CallOption {
+	return CallFlags(command.LockActionFlagAutoUnlock)
+}
+
+// CallPinSource sets the source of the security pin. If it is given, the pin argument of the operation will be
+// ignored (so it can be empty).
+func CallPinSource(source PinSource) CallOption {
+	return func(o *callOptions) {
+		o.pinSource = source
+	}
+}
+
+func (o callOptions) checkNameSuffix() error {
+	if o.nameSuffix != nil && len(*o.nameSuffix) > command.NameSuffixLength {
+		return fmt.Errorf("%w: %q", InvalidNameSuffixError, *o.nameSuffix)
+	}
+	return nil
+}
+
+// withCallOptions returns a context which carries the given options. Options of an outer operation (which are
+// already carried by the context) are inherited.
+func withCallOptions(ctx context.Context, opts []CallOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+
+	options := callOptionsFrom(ctx)
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, options)
+}
+
+func callOptionsFrom(ctx context.Context) callOptions {
+	if options, ok := ctx.Value(callOptionsKey{}).(callOptions); ok {
+		return options
+	}
+	return callOptions{}
+}
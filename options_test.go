@@ -0,0 +1,128 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func loggingDevice() *scriptedCommunicator {
+	return &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdEnableLogging):
+				return completed()
+			}
+			return nil
+		},
+	}
+}
+
+func TestWithCallOptions(t *testing.T) {
+	ctx := withCallOptions(context.Background(), []CallOption{CallTimeout(time.Second), CallForce()})
+	ctx = withCallOptions(ctx, []CallOption{CallNameSuffix("Alice"), CallAutoUnlock()})
+
+	options := callOptionsFrom(ctx)
+	assert.Equal(t, time.Second, options.timeout)
+	assert.Equal(t, uint8(command.LockActionFlagForce|command.LockActionFlagAutoUnlock), options.flags)
+	assert.Equal(t, "Alice", *options.nameSuffix)
+
+	assert.Equal(t, callOptions{}, callOptionsFrom(context.Background()))
+}
+
+func TestClient_checkPrecondition_CallTimeout(t *testing.T) {
+	client := connectedTestClient(&scriptedCommunicator{}).WithTimeout(3 * time.Second)
+
+	_, timeout, err := client.checkPrecondition(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, timeout)
+
+	_, timeout, err = client.checkPrecondition(withCallOptions(context.Background(), []CallOption{CallTimeout(time.Second)}))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, timeout)
+}
+
+func TestClient_PerformLock_FlagsAndNameSuffix(t *testing.T) {
+	com := lockDevice(command.LockStateSmartLockUnlocked, completed())
+	client := connectedTestClient(com)
+
+	err := client.PerformLock(context.Background(), 13, CallForce(), CallNameSuffix("Alice"))
+
+	assert.NoError(t, err)
+	actions := com.sentOf(command.IdLockAction)
+	if assert.Len(t, actions, 1) {
+		payload := actions[0].Payload()
+		assert.Equal(t, byte(command.LockActionLock), payload[0])
+		assert.Equal(t, byte(command.LockActionFlagForce), payload[5])
+		assert.Equal(t, append([]byte("Alice"), make([]byte, 15)...), payload[6:26])
+	}
+}
+
+func TestClient_PerformLock_WithoutOptions(t *testing.T) {
+	com := lockDevice(command.LockStateSmartLockUnlocked, completed())
+	client := connectedTestClient(com)
+
+	err := client.PerformLock(context.Background(), 13)
+
+	assert.NoError(t, err)
+	actions := com.sentOf(command.IdLockAction)
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, byte(0), actions[0].Payload()[5])
+		assert.Len(t, actions[0].Payload(), 1+4+1+32, "no name suffix expected")
+	}
+}
+
+func TestClient_CallRetry(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), completed())
+	client := connectedTestClient(com)
+
+	err := client.PerformUnlock(context.Background(), 13, CallRetry(testRetryPolicy))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, com.sentActions())
+}
+
+func TestClient_CallWithoutRetry(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), completed())
+	client := connectedTestClient(com).WithRetry(testRetryPolicy)
+
+	err := client.PerformUnlock(context.Background(), 13, CallWithoutRetry())
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BUSY)
+	assert.Equal(t, 1, com.sentActions())
+}
+
+func TestClient_CallPinSource(t *testing.T) {
+	com := loggingDevice()
+	client := connectedTestClient(com)
+
+	err := client.EnableLogging(context.Background(), "", CallPinSource(func(context.Context) (string, error) {
+		return "1234", nil
+	}))
+
+	assert.NoError(t, err)
+	sent := com.sentOf(command.IdEnableLogging)
+	if assert.Len(t, sent, 1) {
+		payload := sent[0].Payload()
+		assert.Equal(t, []byte{0xD2, 0x04}, payload[len(payload)-2:], "pin 1234 (little endian)")
+	}
+}
+
+func TestClient_CallPinSource_Error(t *testing.T) {
+	com := loggingDevice()
+	client := connectedTestClient(com)
+	sourceErr := fmt.Errorf("secret store unavailable")
+
+	err := client.EnableLogging(context.Background(), "", CallPinSource(func(context.Context) (string, error) {
+		return "", sourceErr
+	}))
+
+	assert.ErrorIs(t, err, sourceErr)
+	assert.Empty(t, com.sent)
+}
@@ -0,0 +1,106 @@
+package nuki
+
+import (
+	"context"
+	"sync"
+)
+
+// priority defines the order in which queued operations will get access to the device communication.
+type priority uint8
+
+const (
+	// priorityLow is used for long-running transfers such as log entry streams
+	priorityLow = priority(0x00)
+	// priorityNormal is used for short requests such as reading the states
+	priorityNormal = priority(0x01)
+	// priorityHigh is used for lock-, unlock- and open-actions
+	priorityHigh = priority(0x02)
+)
+
+type ticket struct {
+	prio  priority
+	seq   uint64
+	ready chan struct{}
+}
+
+// scheduler serializes the access to the device communication. Only one operation can communicate with the device
+// at the same time. Waiting operations will be granted by their priority and (for the same priority) in order of arrival.
+type scheduler struct {
+	mu      sync.Mutex
+	busy    bool
+	seq     uint64
+	waiting []*ticket
+}
+
+// acquire will block until the caller has the exclusive access to the device communication or the context is done.
+// After the work is done, release must be called.
+func (s *scheduler) acquire(ctx context.Context, prio priority) error {
+	s.mu.Lock()
+	if !s.busy {
+		s.busy = true
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.seq++
+	t := &ticket{
+		prio:  prio,
+		seq:   s.seq,
+		ready: make(chan struct{}),
+	}
+	s.waiting = append(s.waiting, t)
+	s.mu.Unlock()
+
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		select {
+		case <-t.ready:
+			//the access was granted in the meantime: pass it to the next one
+			s.handOver()
+		default:
+			s.remove(t)
+		}
+
+		return ctx.Err()
+	}
+}
+
+// release will pass the exclusive access to the next waiting operation.
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handOver()
+}
+
+func (s *scheduler) handOver() {
+	if len(s.waiting) == 0 {
+		s.busy = false
+		return
+	}
+
+	next := 0
+	for i, t := range s.waiting {
+		if t.prio > s.waiting[next].prio || (t.prio == s.waiting[next].prio && t.seq < s.waiting[next].seq) {
+			next = i
+		}
+	}
+
+	t := s.waiting[next]
+	s.waiting = append(s.waiting[:next], s.waiting[next+1:]...)
+	close(t.ready)
+}
+
+func (s *scheduler) remove(toRemove *ticket) {
+	for i, t := range s.waiting {
+		if t == toRemove {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package nuki
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Priority(t *testing.T) {
+	toTest := &scheduler{}
+	assert.NoError(t, toTest.acquire(context.Background(), priorityNormal))
+
+	var mu sync.Mutex
+	var order []priority
+	wg := sync.WaitGroup{}
+
+	for _, prio := range []priority{priorityLow, priorityNormal, priorityHigh} {
+		wg.Add(1)
+		go func(prio priority) {
+			defer wg.Done()
+
+			assert.NoError(t, toTest.acquire(context.Background(), prio))
+			mu.Lock()
+			order = append(order, prio)
+			mu.Unlock()
+			toTest.release()
+		}(prio)
+
+		//ensure the order of arrival
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	toTest.release()
+	wg.Wait()
+
+	assert.Equal(t, []priority{priorityHigh, priorityNormal, priorityLow}, order)
+}
+
+func TestScheduler_AcquireCanceled(t *testing.T) {
+	toTest := &scheduler{}
+	assert.NoError(t, toTest.acquire(context.Background(), priorityNormal))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, toTest.acquire(ctx, priorityHigh))
+
+	toTest.release()
+	assert.NoError(t, toTest.acquire(context.Background(), priorityNormal))
+}
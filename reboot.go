@@ -3,39 +3,40 @@ package nuki
 import (
 	"context"
 	"fmt"
+	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"time"
 )
 
 // Reboot will trigger a reboot of the connected device.
-// After the reboot you have to re-establish the connection to the device via EstablishConnection!
-func (c *Client) Reboot(ctx context.Context, pin string) error {
-	parsedPin, err := c.checkPreconditionAndParsePin(pin)
+// After the reboot you have to re-establish the connection to the device via EstablishConnection! Except the
+// automatic reconnection is enabled (see WithReconnect): then the connection will be re-established in background.
+func (c *Client) Reboot(ctx context.Context, pin string, opts ...CallOption) error {
+	ctx = withCallOptions(ctx, opts)
+
+	parsedPin, err := c.checkPreconditionAndParsePin(ctx, pin)
 	if err != nil {
 		return err
 	}
 
-	if c.client == nil {
-		return ConnectionNotEstablishedError
-	}
-	if c.udioCom == nil {
-		return UnauthenticatedError
-	}
-
-	err = c.udioCom.Send(command.NewRequest(command.IdChallenge))
-	if err != nil {
-		return fmt.Errorf("unable to send request for challenge: %w", err)
-	}
+	return c.exchange(ctx, priorityNormal, false, func(com communication.Communicator, timeout time.Duration) error {
+		nonce, err := c.requestTrustedChallenge(ctx, com, timeout)
+		if err != nil {
+			return err
+		}
 
-	challenge, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdChallenge, c.responseTimeout)
-	if err != nil {
-		return fmt.Errorf("error while waiting for challenge: %w", err)
-	}
+		err = com.Send(command.NewRequestReboot(parsedPin, nonce))
+		if err != nil {
+			return fmt.Errorf("unable to send action: %w", err)
+		}
 
-	err = c.udioCom.Send(command.NewRequestReboot(parsedPin, challenge.AsChallengeCommand().Nonce()))
-	if err != nil {
-		return fmt.Errorf("unable to send action: %w", err)
-	}
+		c.mu.RLock()
+		reconnect := c.reconnectPolicy != nil
+		c.mu.RUnlock()
 
-	c.Close()
-	return nil
+		if !reconnect {
+			c.close()
+		}
+		return nil
+	})
 }
@@ -0,0 +1,236 @@
+package nuki
+
+import (
+	"context"
+	"github.com/go-ble/ble"
+	"github.com/tarent/go-nuki/logger"
+	"time"
+)
+
+type ConnectionState uint8
+
+const (
+	ConnectionStateDisconnected = ConnectionState(0x00)
+	ConnectionStateConnected    = ConnectionState(0x01)
+	ConnectionStateReconnecting = ConnectionState(0x02)
+)
+
+// ReconnectPolicy defines how the client will try to re-establish a lost connection. Durations which are not set
+// (zero or negative) will be taken from DefaultReconnectPolicy.
+type ReconnectPolicy struct {
+	// MaxAttempts is the maximum count of reconnection attempts. Zero means unlimited attempts.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first attempt. It will be doubled after each failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the time to wait between two attempts.
+	MaxBackoff time.Duration
+	// ConnectTimeout is the maximum duration of one attempt.
+	ConnectTimeout time.Duration
+}
+
+// DefaultReconnectPolicy is a reasonable policy for devices which are in reach most of the time.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MaxAttempts:    10,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     30 * time.Second,
+	ConnectTimeout: 30 * time.Second,
+}
+
+// WithReconnect enables the automatic reconnection: if the connection to the device is lost, the client will
+// re-establish the connection (including the re-authentication) in background. Operations which are called while
+// reconnecting will wait until the reconnection is done. An idempotent operation (such as ReadStates) which was
+// in-flight while the connection was lost will be replayed after the reconnection. Actions (such as lock actions)
+// will never be replayed! The connection state changes will be published as events (see Subscribe).
+func (c *Client) WithReconnect(policy ReconnectPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	policy = policy.withDefaults()
+	c.reconnectPolicy = &policy
+	return c
+}
+
+// withDefaults returns the policy with the durations of DefaultReconnectPolicy for all durations which are not set.
+// Otherwise, the attempts would follow one another without any delay and timeout.
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultReconnectPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultReconnectPolicy.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.ConnectTimeout <= 0 {
+		p.ConnectTimeout = DefaultReconnectPolicy.ConnectTimeout
+	}
+	return p
+}
+
+// watchConnection will handle the loss of the given connection as soon as it is disconnected.
+func (c *Client) watchConnection(bleClient ble.Client) {
+	go func() {
+		<-bleClient.Disconnected()
+		c.handleConnectionLoss(bleClient)
+	}()
+}
+
+// isConnectionLost checks if the given connection was disconnected.
+func isConnectionLost(bleClient ble.Client) bool {
+	select {
+	case <-bleClient.Disconnected():
+		return true
+	default:
+		return false
+	}
+}
+
+// handleConnectionLoss will release all resources of the given (lost) connection and starts the reconnection if
+// enabled. It is safe to call this function multiple times for the same connection.
+func (c *Client) handleConnectionLoss(bleClient ble.Client) {
+	c.mu.Lock()
+	if c.client == nil || c.client != bleClient {
+		//the connection was closed intentionally or is already handled
+		c.mu.Unlock()
+		return
+	}
+
+	c.closeResources(false)
+
+	policy := c.reconnectPolicy
+	address := c.address
+	var reconnected, stop chan struct{}
+	if policy != nil {
+		reconnected = make(chan struct{})
+		stop = make(chan struct{})
+		c.reconnected = reconnected
+		c.stopReconnect = stop
+	}
+	c.mu.Unlock()
+
+	if logger.Info != nil {
+		logger.Info.Printf("[CONNECTION] The connection to %s is lost.", address)
+	}
+	c.publishConnectionState(ConnectionStateDisconnected)
+
+	if policy != nil {
+		go c.reconnect(*policy, address, reconnected, stop)
+	}
+}
+
+func (c *Client) reconnect(policy ReconnectPolicy, address ble.Addr, reconnected, stop chan struct{}) {
+	defer func() {
+		c.mu.Lock()
+		if c.reconnected == reconnected {
+			c.reconnected = nil
+			c.stopReconnect = nil
+		}
+		c.mu.Unlock()
+
+		close(reconnected)
+	}()
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; policy.MaxAttempts == 0 || attempt <= policy.MaxAttempts; attempt++ {
+		c.publishConnectionState(ConnectionStateReconnecting)
+
+		select {
+		case <-time.After(backoff):
+		case <-stop:
+			return
+		}
+
+		err := c.reconnectOnce(policy, address, stop)
+		if err == nil {
+			return
+		}
+		if logger.Info != nil {
+			logger.Info.Printf("[CONNECTION] Reconnection attempt #%d to %s failed: %s", attempt, address, err.Error())
+		}
+
+		backoff *= 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+
+	c.publishConnectionState(ConnectionStateDisconnected)
+}
+
+func (c *Client) reconnectOnce(policy ReconnectPolicy, address ble.Addr, stop chan struct{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), policy.ConnectTimeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := c.queue.acquire(ctx, priorityHigh); err != nil {
+		return err
+	}
+	defer c.queue.release()
+
+	err := c.establishConnection(ctx, address)
+	if err != nil {
+		//do not leave a half established connection
+		c.close()
+	}
+	return err
+}
+
+// awaitReconnection will block while a reconnection is in progress.
+func (c *Client) awaitReconnection(ctx context.Context) error {
+	c.mu.RLock()
+	reconnected := c.reconnected
+	c.mu.RUnlock()
+
+	if reconnected == nil {
+		return nil
+	}
+
+	select {
+	case <-reconnected:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) isReconnecting() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.reconnected != nil
+}
+
+// recoverConnection checks if the given connection was lost. If so, it will wait for the reconnection and returns
+// true if the reconnection was successful. So that the caller can replay its request.
+func (c *Client) recoverConnection(ctx context.Context, bleClient ble.Client) bool {
+	if bleClient == nil || !isConnectionLost(bleClient) {
+		return false
+	}
+	c.handleConnectionLoss(bleClient)
+
+	if err := c.awaitReconnection(ctx); err != nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client != nil && c.udioCom != nil
+}
+
+func (c *Client) publishConnectionState(state ConnectionState) {
+	c.events.publish(Event{
+		Type:            EventTypeConnection,
+		ReceivedAt:      time.Now(),
+		DeviceType:      c.GetDeviceType(),
+		ConnectionState: state,
+	})
+}
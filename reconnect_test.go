@@ -0,0 +1,30 @@
+package nuki
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClient_WithReconnect_Defaults(t *testing.T) {
+	client := NewClient(nil).WithReconnect(ReconnectPolicy{})
+
+	assert.Equal(t, DefaultReconnectPolicy.InitialBackoff, client.reconnectPolicy.InitialBackoff)
+	assert.Equal(t, DefaultReconnectPolicy.MaxBackoff, client.reconnectPolicy.MaxBackoff)
+	assert.Equal(t, DefaultReconnectPolicy.ConnectTimeout, client.reconnectPolicy.ConnectTimeout)
+	assert.Equal(t, 0, client.reconnectPolicy.MaxAttempts, "zero attempts means unlimited")
+}
+
+func TestClient_WithReconnect_KeepsGivenValues(t *testing.T) {
+	policy := ReconnectPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Second, ConnectTimeout: 5 * time.Second}
+
+	client := NewClient(nil).WithReconnect(policy)
+
+	assert.Equal(t, policy, *client.reconnectPolicy)
+}
+
+func TestReconnectPolicy_withDefaults_MaxBackoffBelowInitial(t *testing.T) {
+	policy := ReconnectPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Second}.withDefaults()
+
+	assert.Equal(t, time.Minute, policy.MaxBackoff)
+}
@@ -0,0 +1,193 @@
+package nuki
+
+import (
+	"context"
+	"errors"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how the client will retry operations which failed because of a transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum count of attempts (including the first one).
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first retry. It will be doubled after each failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the time to wait between two attempts. 0 means no limit.
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0.0 - 1.0) of the backoff which will be randomly added or subtracted. So that
+	// several clients do not retry at the same time. Values outside this range will be clamped.
+	Jitter float64
+	// RetryableErrors are the errors (checked by errors.Is) which are worth a retry. If empty, the
+	// DefaultRetryableErrors are used.
+	RetryableErrors []error
+}
+
+// DefaultRetryableErrors are the errors which are transient in most cases.
+var DefaultRetryableErrors = []error{
+	communication.K_ERROR_BUSY,
+	communication.K_ERROR_BAD_NONCE,
+	communication.TimeoutErr,
+}
+
+// DefaultRetryPolicy is a reasonable policy for the most use cases.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Jitter:         0.2,
+}
+
+// WithRetry enables the retry of failed operations. Each attempt will request a new challenge from the device.
+// Idempotent operations (such as ReadStates) will be retried if they failed with a retryable error. Actions (such as
+// lock actions) will only be retried if the current states (see ReadStates) show that the failed attempt did not
+// take effect. Actions whose effect can not be determined by the states (for example PerformAction) will never be
+// retried.
+func (c *Client) WithRetry(policy RetryPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retryPolicy = &policy
+	return c
+}
+
+// isRetryable checks if the given error is one of the retryable errors of the policy.
+func (p *RetryPolicy) isRetryable(err error) bool {
+	retryableErrors := p.RetryableErrors
+	if len(retryableErrors) == 0 {
+		retryableErrors = DefaultRetryableErrors
+	}
+
+	for _, retryableError := range retryableErrors {
+		if errors.Is(err, retryableError) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the time to wait before the given attempt (starting with 2 for the first retry).
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 2; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if jitter := math.Min(p.Jitter, 1); jitter > 0 {
+		backoff += time.Duration((rand.Float64()*2 - 1) * jitter * float64(backoff))
+	}
+	return backoff
+}
+
+// retry will call the given function until it succeeds or the retry policy (if any) is exhausted. If canRetry is
+// given, it will be asked before each retry: so the caller can prevent the retry (for example if the failed attempt
+// already took effect). The retry policy can be overridden per call (see CallRetry and CallWithoutRetry).
+func (c *Client) retry(ctx context.Context, canRetry func(ctx context.Context) bool, fn func() error) error {
+	c.mu.RLock()
+	policy := c.retryPolicy
+	c.mu.RUnlock()
+
+	if options := callOptionsFrom(ctx); options.noRetry {
+		policy = nil
+	} else if options.retryPolicy != nil {
+		policy = options.retryPolicy
+	}
+
+	err := fn()
+	if policy == nil {
+		return err
+	}
+
+	for attempt := 2; err != nil && attempt <= policy.MaxAttempts; attempt++ {
+		if !policy.isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if canRetry != nil && !canRetry(ctx) {
+			return err
+		}
+
+		if logger.Info != nil {
+			logger.Info.Printf("[RETRY] Attempt #%d failed: %s", attempt-1, err.Error())
+		}
+
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+
+		err = fn()
+	}
+
+	return err
+}
+
+// notAppliedByStates returns a function which checks if an action did not take effect. The given function
+// should return true only if the states show clearly that the action was not performed.
+func (c *Client) notAppliedByStates(notApplied func(states command.StatesCommand) bool) func(ctx context.Context) bool {
+	if notApplied == nil {
+		return func(context.Context) bool {
+			return false
+		}
+	}
+
+	return func(ctx context.Context) bool {
+		//the probe itself must not be retried: the retry of the action decides about the next attempt
+		states, err := c.ReadStates(withCallOptions(ctx, []CallOption{CallWithoutRetry()}))
+		if err != nil {
+			return false
+		}
+		return notApplied(states)
+	}
+}
+
+// lockActionNotApplied returns a function which checks if the given lock action did not take effect. Returns nil
+// if the effect of the action can not be determined by the states.
+func lockActionNotApplied(action command.LockAction) func(states command.StatesCommand) bool {
+	switch action {
+	case command.LockActionLock, command.LockActionFullLock:
+		return func(states command.StatesCommand) bool {
+			switch states.LockState() {
+			case command.LockStateSmartLockUnlocked, command.LockStateSmartLockUnlatched, command.LockStateSmartLockUnlockedLockAndGoActive:
+				return true
+			}
+			return false
+		}
+	case command.LockActionUnlock, command.LockActionUnlatch:
+		return func(states command.StatesCommand) bool {
+			return states.LockState() == command.LockStateLocked
+		}
+	}
+	return nil
+}
+
+// openActionNotApplied returns a function which checks if the given open action did not take effect. Returns nil
+// if the effect of the action can not be determined by the states.
+func openActionNotApplied(action command.OpenAction) func(states command.StatesCommand) bool {
+	switch action {
+	case command.OpenActionActivateRTO:
+		return func(states command.StatesCommand) bool {
+			return states.LockState() == command.LockStateLocked && states.NukiState() != command.NukiStateOpenerContinuousMode
+		}
+	case command.OpenActionDeactivateRTO:
+		return func(states command.StatesCommand) bool {
+			return states.LockState() == command.LockStateOpenerRTOActive
+		}
+	case command.OpenActionActivateCm:
+		return func(states command.StatesCommand) bool {
+			return states.NukiState() == command.NukiStateDoorMode
+		}
+	case command.OpenActionDeactivateCm:
+		return func(states command.StatesCommand) bool {
+			return states.NukiState() == command.NukiStateOpenerContinuousMode
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+// scriptedCommunicator answers each sent command with the responses of the given function.
+type scriptedCommunicator struct {
+	communication.Communicator
+
+	respond func(cmd command.Command) []command.Command
+	sent    []command.Command
+	pending []command.Command
+}
+
+func (s *scriptedCommunicator) Send(cmd command.Command) error {
+	s.sent = append(s.sent, cmd)
+	s.pending = s.respond(cmd)
+	return nil
+}
+
+func (s *scriptedCommunicator) WaitForSpecificResponse(ctx context.Context, expectedType command.Id, timeout time.Duration) (command.Command, error) {
+	for {
+		next, err := s.WaitForResponse(ctx, timeout)
+		if err != nil || next.Is(expectedType) {
+			return next, err
+		}
+	}
+}
+
+func (s *scriptedCommunicator) sentActions() int {
+	count := 0
+	for _, cmd := range s.sent {
+		if cmd.Is(command.IdLockAction) {
+			count++
+		}
+	}
+	return count
+}
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func lockDevice(lockState command.LockState, actionResponses ...[]command.Command) *scriptedCommunicator {
+	actions := 0
+	return &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdKeyturnerStates):
+				return []command.Command{testStates(lockState, command.LockActionCompletionStatusSuccess)}
+			case cmd.Is(command.IdLockAction):
+				actions++
+				return actionResponses[actions-1]
+			}
+			return nil
+		},
+	}
+}
+
+func busy() []command.Command {
+	return []command.Command{command.NewCommand(command.IdErrorReport, []byte{0x45, byte(command.IdLockAction), 0x00})}
+}
+
+func completed() []command.Command {
+	return []command.Command{
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusAccepted)}),
+		command.NewCommand(command.IdStatus, []byte{byte(command.CompletionStatusComplete)}),
+	}
+}
+
+func performTestUnlock(client *Client, com communication.Communicator) error {
+	client.client = connectedBleClient{}
+	client.udioCom = com
+
+	return client.performAction(context.Background(), priorityHigh, true, lockActionNotApplied(command.LockActionUnlock), nil, func(nonce []byte) command.Command {
+		return command.NewLockAction(command.LockActionUnlock, 13, 0, nil, nonce)
+	})
+}
+
+func TestClient_Retry_ActionNotApplied(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), completed())
+
+	err := performTestUnlock(NewClient(nil).WithRetry(testRetryPolicy), com)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, com.sentActions())
+}
+
+func TestClient_Retry_ActionAlreadyApplied(t *testing.T) {
+	com := lockDevice(command.LockStateSmartLockUnlocking, busy(), completed())
+
+	err := performTestUnlock(NewClient(nil).WithRetry(testRetryPolicy), com)
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BUSY)
+	assert.Equal(t, 1, com.sentActions(), "the action must not be retried if it may have taken effect")
+}
+
+func TestClient_Retry_Exhausted(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), busy(), busy(), completed())
+
+	err := performTestUnlock(NewClient(nil).WithRetry(testRetryPolicy), com)
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BUSY)
+	assert.Equal(t, 3, com.sentActions())
+}
+
+func TestClient_Retry_Disabled(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), completed())
+
+	err := performTestUnlock(NewClient(nil), com)
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BUSY)
+	assert.Equal(t, 1, com.sentActions())
+}
+
+func TestClient_Retry_NotRetryable(t *testing.T) {
+	badPin := []command.Command{command.NewCommand(command.IdErrorReport, []byte{0x21, byte(command.IdLockAction), 0x00})}
+	com := lockDevice(command.LockStateLocked, badPin, completed())
+
+	err := performTestUnlock(NewClient(nil).WithRetry(testRetryPolicy), com)
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BAD_PIN)
+	assert.Equal(t, 1, com.sentActions())
+}
+
+func TestClient_Retry_ProbeNotRetried(t *testing.T) {
+	com := lockDevice(command.LockStateLocked, busy(), completed())
+	respond := com.respond
+	com.respond = func(cmd command.Command) []command.Command {
+		if cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdKeyturnerStates) {
+			return []command.Command{command.NewCommand(command.IdErrorReport, []byte{0x45, byte(command.IdRequestData), 0x00})}
+		}
+		return respond(cmd)
+	}
+
+	err := performTestUnlock(NewClient(nil).WithRetry(testRetryPolicy), com)
+
+	assert.ErrorIs(t, err, communication.K_ERROR_BUSY)
+	assert.Equal(t, 1, com.sentActions(), "the action must not be retried if the states are unknown")
+	assert.Len(t, com.sentOf(command.IdRequestData), 2, "one challenge and one states request expected")
+}
+
+func TestRetryPolicy_isRetryable(t *testing.T) {
+	assert.True(t, DefaultRetryPolicy.isRetryable(fmt.Errorf("wrapped: %w", communication.TimeoutErr)))
+	assert.False(t, DefaultRetryPolicy.isRetryable(communication.K_ERROR_MOTOR_BLOCKED))
+
+	custom := RetryPolicy{RetryableErrors: []error{communication.K_ERROR_MOTOR_BLOCKED}}
+	assert.True(t, custom.isRetryable(communication.K_ERROR_MOTOR_BLOCKED))
+	assert.False(t, custom.isRetryable(communication.TimeoutErr))
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	assert.Equal(t, time.Second, policy.backoff(2))
+	assert.Equal(t, 2*time.Second, policy.backoff(3))
+	assert.Equal(t, 3*time.Second, policy.backoff(4))
+
+	unlimited := RetryPolicy{InitialBackoff: time.Second}
+	assert.Equal(t, time.Second, unlimited.backoff(2))
+	assert.Equal(t, 2*time.Second, unlimited.backoff(3))
+	assert.Equal(t, 4*time.Second, unlimited.backoff(4), "zero max backoff means no limit")
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(2)
+		assert.True(t, backoff >= 500*time.Millisecond && backoff <= 1500*time.Millisecond, backoff.String())
+	}
+
+	policy.Jitter = 5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(2)
+		assert.True(t, backoff >= 0 && backoff <= 2*time.Second, backoff.String())
+	}
+}
@@ -0,0 +1,336 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-ble/ble"
+	"github.com/kevinburke/nacl"
+	"github.com/tarent/go-nuki/communication/command"
+	"github.com/tarent/go-nuki/logger"
+	"sync"
+	"time"
+)
+
+// DefaultIdleTimeout is the duration after an unused session will be disconnected.
+const DefaultIdleTimeout = 30 * time.Second
+
+// connectionHolder is someone who holds a connection slot of a ConnectionLimiter.
+type connectionHolder interface {
+	// disconnectIfIdle will disconnect (and release the slot) if the connection is not in use at the moment.
+	disconnectIfIdle()
+}
+
+// ConnectionLimiter limits the count of simultaneous connections. Normally one limiter should be shared between
+// all sessions of the same bluetooth adapter. If all connections are in use, idle sessions will be disconnected
+// to make room for the waiting ones.
+type ConnectionLimiter struct {
+	mu      sync.Mutex
+	max     int
+	holders map[connectionHolder]struct{}
+	signal  chan struct{}
+}
+
+// NewConnectionLimiter creates a new limiter which allows the given count of simultaneous connections. Values below 1
+// will be raised to 1: otherwise no connection could ever be established.
+func NewConnectionLimiter(maxConnections int) *ConnectionLimiter {
+	if maxConnections < 1 {
+		maxConnections = 1
+	}
+	return &ConnectionLimiter{
+		max:     maxConnections,
+		holders: map[connectionHolder]struct{}{},
+		signal:  make(chan struct{}),
+	}
+}
+
+func (l *ConnectionLimiter) acquire(ctx context.Context, holder connectionHolder) error {
+	for {
+		l.mu.Lock()
+		if len(l.holders) < l.max {
+			l.holders[holder] = struct{}{}
+			l.mu.Unlock()
+			return nil
+		}
+
+		others := make([]connectionHolder, 0, len(l.holders))
+		for other := range l.holders {
+			others = append(others, other)
+		}
+		signal := l.signal
+		l.mu.Unlock()
+
+		//ask the others to make room
+		for _, other := range others {
+			other.disconnectIfIdle()
+		}
+
+		select {
+		case <-signal:
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *ConnectionLimiter) release(holder connectionHolder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.holders[holder]; !ok {
+		return
+	}
+	delete(l.holders, holder)
+
+	//wakeup all waiters
+	close(l.signal)
+	l.signal = make(chan struct{})
+}
+
+// Session is a lazy connection to one paired nuki device. The connection will be established on the first
+// usage and will be disconnected after the session was unused for the idle timeout. So several operations can
+// be done with one connection without keeping the connection open all the time (which costs battery of the device).
+type Session struct {
+	address       ble.Addr
+	privateKey    nacl.Key
+	publicKey     nacl.Key
+	nukiPublicKey []byte
+	authId        command.AuthorizationId
+
+	idleTimeout time.Duration
+	limiter     *ConnectionLimiter
+
+	// establish will connect and authenticate the client (see establishConnection)
+	establish func(ctx context.Context) error
+
+	connecting sync.Mutex
+
+	mu        sync.Mutex
+	client    *Client
+	connected bool
+	inUse     int
+	idleTimer *time.Timer
+}
+
+// NewSession creates a new session for the given (already paired) device. The authentication data should
+// be the same which is used for pairing before. The connection will not be established until the session is used.
+func NewSession(bleDevice ble.Device, deviceAddress ble.Addr, privateKey, publicKey nacl.Key, nukiPublicKey []byte, authId command.AuthorizationId) *Session {
+	session := &Session{
+		address:       deviceAddress,
+		privateKey:    privateKey,
+		publicKey:     publicKey,
+		nukiPublicKey: nukiPublicKey,
+		authId:        authId,
+		idleTimeout:   DefaultIdleTimeout,
+		client:        NewClient(bleDevice),
+	}
+	session.establish = session.establishConnection
+	return session
+}
+
+// NewSessionFromCredentials creates a new session for the device of the given credentials. See NewSession.
+func NewSessionFromCredentials(bleDevice ble.Device, credentials *Credentials) *Session {
+	return NewSession(bleDevice, credentials.Address(), credentials.PrivateKey, credentials.PublicKey, credentials.NukiPublicKey, credentials.AuthId)
+}
+
+// WithIdleTimeout sets the duration after an unused session will be disconnected.
+func (s *Session) WithIdleTimeout(duration time.Duration) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idleTimeout = duration
+	return s
+}
+
+// WithConnectionLimiter sets the limiter which will be used for limit the simultaneous connections.
+func (s *Session) WithConnectionLimiter(limiter *ConnectionLimiter) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limiter = limiter
+	return s
+}
+
+// WithIdentityPinning pins the session to the device of the given credentials. See Client.WithIdentityPinning.
+func (s *Session) WithIdentityPinning(credentials *Credentials) *Session {
+	s.client.WithIdentityPinning(credentials)
+	return s
+}
+
+// WithTimeout sets the timeout which is used for each response waiting. See Client.WithTimeout.
+func (s *Session) WithTimeout(duration time.Duration) *Session {
+	s.client.WithTimeout(duration)
+	return s
+}
+
+// Do will call the given function with a connected and authenticated client. The connection will be established
+// if necessary. The session is safe for concurrent use: all calls will share the same connection.
+func (s *Session) Do(ctx context.Context, fn func(client *Client) error) error {
+	s.mu.Lock()
+	s.inUse++
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	s.mu.Unlock()
+	defer s.done()
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+
+	return fn(s.client)
+}
+
+// Subscribe will return a channel which receives all events of the device. The subscription will survive the
+// disconnection of the session. See Client.Subscribe.
+func (s *Session) Subscribe(ctx context.Context) <-chan Event {
+	return s.client.Subscribe(ctx)
+}
+
+// IsConnected returns true if the session holds an established connection at the moment.
+func (s *Session) IsConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connected && s.client.isConnected()
+}
+
+// Close will disconnect the session. The session can be reused afterwards (it will connect again).
+func (s *Session) Close() error {
+	s.connecting.Lock()
+	defer s.connecting.Unlock()
+
+	s.mu.Lock()
+	connected := s.disconnect()
+	s.mu.Unlock()
+
+	if !connected {
+		return nil
+	}
+	return s.closeClient()
+}
+
+func (s *Session) connect(ctx context.Context) error {
+	s.connecting.Lock()
+	defer s.connecting.Unlock()
+
+	s.mu.Lock()
+	if s.connected && s.client.isConnected() {
+		s.mu.Unlock()
+		return nil
+	}
+	lost := s.connected && s.disconnect()
+	limiter := s.limiter
+	s.mu.Unlock()
+
+	if lost {
+		//the connection was lost in the meantime
+		s.closeClient()
+	}
+
+	if limiter != nil {
+		if err := limiter.acquire(ctx, s); err != nil {
+			return fmt.Errorf("unable to acquire a connection: %w", err)
+		}
+	}
+
+	err := s.establish(ctx)
+	if err != nil {
+		s.client.Close()
+		if limiter != nil {
+			limiter.release(s)
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	s.connected = true
+	s.mu.Unlock()
+
+	if logger.Info != nil {
+		logger.Info.Printf("[SESSION] Connected to %s.", s.address)
+	}
+
+	return nil
+}
+
+func (s *Session) establishConnection(ctx context.Context) error {
+	err := s.client.EstablishConnection(ctx, s.address)
+	if err != nil {
+		return err
+	}
+
+	if s.client.isAuthenticated() {
+		//the client has re-authenticated itself with the credentials of the last connection
+		return nil
+	}
+
+	return s.client.Authenticate(s.privateKey, s.publicKey, s.nukiPublicKey, s.authId)
+}
+
+func (s *Session) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inUse--
+	if s.inUse > 0 || !s.connected {
+		return
+	}
+
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	s.idleTimer = time.AfterFunc(s.idleTimeout, s.disconnectIfIdle)
+}
+
+func (s *Session) disconnectIfIdle() {
+	if !s.connecting.TryLock() {
+		//the session is connecting at the moment: so it is not idle
+		return
+	}
+	defer s.connecting.Unlock()
+
+	s.mu.Lock()
+	if s.inUse > 0 || !s.connected {
+		s.mu.Unlock()
+		return
+	}
+	s.disconnect()
+	s.mu.Unlock()
+
+	if logger.Info != nil {
+		logger.Info.Printf("[SESSION] Disconnect idle session of %s.", s.address)
+	}
+	s.closeClient()
+}
+
+// disconnect will mark the session as disconnected. Returns true if the session was connected: then the caller
+// must close the client afterwards (see closeClient). The caller must hold the lock of the session.
+func (s *Session) disconnect() bool {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	if !s.connected {
+		return false
+	}
+	s.connected = false
+	return true
+}
+
+// closeClient will close the connection and release the connection slot. The caller must hold the connecting lock
+// but not the lock of the session: closing the client waits until the running operations of the client are done.
+func (s *Session) closeClient() error {
+	err := s.client.Close()
+
+	s.mu.Lock()
+	limiter := s.limiter
+	s.mu.Unlock()
+
+	if limiter != nil {
+		limiter.release(s)
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package nuki
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+type testHolder struct {
+	limiter *ConnectionLimiter
+	idle    bool
+}
+
+func (t *testHolder) disconnectIfIdle() {
+	if t.idle {
+		t.limiter.release(t)
+	}
+}
+
+func TestConnectionLimiter_DisconnectIdle(t *testing.T) {
+	toTest := NewConnectionLimiter(1)
+
+	idleHolder := &testHolder{limiter: toTest, idle: true}
+	assert.NoError(t, toTest.acquire(context.Background(), idleHolder))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, toTest.acquire(ctx, &testHolder{limiter: toTest}))
+}
+
+func TestConnectionLimiter_AtLeastOne(t *testing.T) {
+	toTest := NewConnectionLimiter(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, toTest.acquire(ctx, &testHolder{limiter: toTest}))
+}
+
+func TestConnectionLimiter_WaitForBusy(t *testing.T) {
+	toTest := NewConnectionLimiter(1)
+
+	busyHolder := &testHolder{limiter: toTest}
+	assert.NoError(t, toTest.acquire(context.Background(), busyHolder))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, toTest.acquire(ctx, &testHolder{limiter: toTest}))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		toTest.release(busyHolder)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, toTest.acquire(ctx, &testHolder{limiter: toTest}))
+}
+
+func TestSession_LazyConnect(t *testing.T) {
+	toTest, connects := fakeSession(lockDevice(command.LockStateLocked))
+	defer toTest.Close()
+
+	assert.False(t, toTest.IsConnected())
+	assert.Equal(t, 0, *connects, "the session must not connect before it is used")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, toTest.Do(context.Background(), func(client *Client) error {
+			return nil
+		}))
+	}
+
+	assert.True(t, toTest.IsConnected())
+	assert.Equal(t, 1, *connects, "the connection should be reused")
+}
+
+func TestSession_IdleDisconnect(t *testing.T) {
+	toTest, connects := fakeSession(lockDevice(command.LockStateLocked))
+	toTest.WithIdleTimeout(10 * time.Millisecond)
+	defer toTest.Close()
+
+	assert.NoError(t, toTest.Do(context.Background(), func(client *Client) error {
+		return nil
+	}))
+	assert.Eventually(t, func() bool {
+		return !toTest.IsConnected()
+	}, time.Second, 5*time.Millisecond)
+
+	//reconnect after idle
+	assert.NoError(t, toTest.Do(context.Background(), func(client *Client) error {
+		assert.True(t, toTest.IsConnected())
+		return nil
+	}))
+	assert.Equal(t, 2, *connects)
+}
+
+func TestSession_NoIdleDisconnectInUse(t *testing.T) {
+	toTest, _ := fakeSession(lockDevice(command.LockStateLocked))
+	toTest.WithIdleTimeout(10 * time.Millisecond)
+	defer toTest.Close()
+
+	assert.NoError(t, toTest.Do(context.Background(), func(client *Client) error {
+		time.Sleep(50 * time.Millisecond)
+		assert.True(t, toTest.IsConnected(), "the session must not be disconnected while it is in use")
+		return nil
+	}))
+}
+
+func TestSession_CloseWaitsWithoutLock(t *testing.T) {
+	toTest, _ := fakeSession(lockDevice(command.LockStateLocked))
+	assert.NoError(t, toTest.Do(context.Background(), func(client *Client) error {
+		return nil
+	}))
+
+	//simulate a running operation of the client
+	assert.NoError(t, toTest.client.queue.acquire(context.Background(), priorityNormal))
+	closed := make(chan error)
+	go func() {
+		closed <- toTest.Close()
+	}()
+
+	isConnected := make(chan bool)
+	go func() {
+		isConnected <- toTest.IsConnected()
+	}()
+	select {
+	case <-isConnected:
+	case <-time.After(time.Second):
+		t.Fatal("the session is locked while closing")
+	}
+
+	toTest.client.queue.release()
+	assert.NoError(t, <-closed)
+	assert.False(t, toTest.IsConnected())
+}
@@ -3,27 +3,122 @@ package nuki
 import (
 	"context"
 	"fmt"
+	"github.com/tarent/go-nuki/communication"
 	"github.com/tarent/go-nuki/communication/command"
+	"time"
 )
 
 // ReadStates will request the current states for the connected and paired nuki device and return the result.
-func (c *Client) ReadStates(ctx context.Context) (command.StatesCommand, error) {
-	if c.client == nil {
-		return nil, ConnectionNotEstablishedError
+func (c *Client) ReadStates(ctx context.Context, opts ...CallOption) (command.StatesCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	var result command.StatesCommand
+
+	err := c.exchange(ctx, priorityNormal, true, func(com communication.Communicator, timeout time.Duration) error {
+		err := com.Send(command.NewRequest(command.IdStates))
+		if err != nil {
+			return fmt.Errorf("unable to send request for device states: %w", err)
+		}
+
+		statesCommand, err := com.WaitForSpecificResponse(ctx, command.IdStates, timeout)
+		if err != nil {
+			return fmt.Errorf("error while waiting for device states: %w", err)
+		}
+
+		result, err = statesCommand.ParseStatesCommand()
+		if err != nil {
+			return fmt.Errorf("invalid device states: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if c.udioCom == nil {
-		return nil, UnauthenticatedError
+
+	c.storeStates(result)
+	return result, nil
+}
+
+// WaitForStatePollInterval is the time after which WaitForState will request the states of the device if the device
+// did not push any states in the meantime.
+var WaitForStatePollInterval = 5 * time.Second
+
+// StatesPredicate checks if the given states are the expected ones.
+type StatesPredicate func(states command.StatesCommand) bool
+
+// LockStateIs returns a predicate which matches if the lock state is one of the given lock states.
+func LockStateIs(lockStates ...command.LockState) StatesPredicate {
+	return func(states command.StatesCommand) bool {
+		for _, lockState := range lockStates {
+			if states.LockState() == lockState {
+				return true
+			}
+		}
+		return false
 	}
+}
 
-	err := c.udioCom.Send(command.NewRequest(command.IdStates))
-	if err != nil {
-		return nil, fmt.Errorf("unable to send request for device states: %w", err)
+// DoorSensorStateIs returns a predicate which matches if the door sensor state is one of the given states.
+func DoorSensorStateIs(doorSensorStates ...command.DoorSensorState) StatesPredicate {
+	return func(states command.StatesCommand) bool {
+		for _, doorSensorState := range doorSensorStates {
+			if states.DoorSensorState() == doorSensorState {
+				return true
+			}
+		}
+		return false
 	}
+}
 
-	statesCommand, err := c.udioCom.WaitForSpecificResponse(ctx, command.IdStates, c.responseTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("error while waiting for device states: %w", err)
+// WaitForState will wait until the states of the device match the given predicate and return the matching states.
+// The states pushed by the device (for example during motor movement) will be used if available. If the device
+// does not push any states within WaitForStatePollInterval, the states will be requested (see ReadStates). The wait
+// can be canceled by the given context.
+func (c *Client) WaitForState(ctx context.Context, predicate StatesPredicate, opts ...CallOption) (command.StatesCommand, error) {
+	ctx = withCallOptions(ctx, opts)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := c.events.subscribe(subCtx)
+
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return nil, ctx.Err()
+			}
+			if e.Type != EventTypeStates || e.States() == nil {
+				continue
+			}
+			if predicate(e.States()) {
+				return e.States(), nil
+			}
+			resetTimer(poll, WaitForStatePollInterval)
+		case <-poll.C:
+			states, err := c.ReadStates(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if predicate(states) {
+				return states, nil
+			}
+			poll.Reset(WaitForStatePollInterval)
+		}
 	}
+}
 
-	return statesCommand.AsStatesCommand(), nil
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
 }
@@ -0,0 +1,172 @@
+package nuki
+
+import (
+	"context"
+	"fmt"
+	"github.com/tarent/go-nuki/logger"
+	"sync"
+	"time"
+)
+
+// DefaultDriftThreshold is the drift of the device clock which will be tolerated by a TimeSync.
+const DefaultDriftThreshold = 30 * time.Second
+
+// DefaultDriftHistorySize is the count of checks which will be remembered by a TimeSync.
+const DefaultDriftHistorySize = 100
+
+// InvalidIntervalError will be returned if a TimeSync should run with an interval which is not positive
+var InvalidIntervalError = fmt.Errorf("the interval must be positive")
+
+// DriftRecord is the result of one check of the device clock.
+type DriftRecord struct {
+	CheckedAt time.Time
+	// Drift is the difference between the device clock and the host clock. A positive drift means that the device
+	// clock is ahead.
+	Drift time.Duration
+	// Corrected is true if the device clock was set to the host clock.
+	Corrected bool
+	// Err contains the error if the check or the correction has failed.
+	Err error
+}
+
+// TimeSync keeps the clock of a device in sync with the host clock. Time-limited keypad codes and time control entries
+// only work properly if the device clock is correct.
+type TimeSync struct {
+	do          func(ctx context.Context, fn func(client *Client) error) error
+	pin         string
+	threshold   time.Duration
+	historySize int
+	now         func() time.Time
+
+	mu      sync.Mutex
+	history []DriftRecord
+}
+
+// NewTimeSync creates a new TimeSync for the given (connected and authenticated) client. The pin is necessary for
+// correcting the device clock.
+func NewTimeSync(client *Client, pin string) *TimeSync {
+	return newTimeSync(func(ctx context.Context, fn func(client *Client) error) error {
+		return fn(client)
+	}, pin)
+}
+
+// NewSessionTimeSync creates a new TimeSync which communicates via the given session. So the device will only be
+// connected while the clock is checked.
+func NewSessionTimeSync(session *Session, pin string) *TimeSync {
+	return newTimeSync(session.Do, pin)
+}
+
+func newTimeSync(do func(ctx context.Context, fn func(client *Client) error) error, pin string) *TimeSync {
+	return &TimeSync{
+		do:          do,
+		pin:         pin,
+		threshold:   DefaultDriftThreshold,
+		historySize: DefaultDriftHistorySize,
+		now:         time.Now,
+	}
+}
+
+// WithThreshold sets the drift which will be tolerated. If the drift is greater, the device clock will be corrected.
+func (t *TimeSync) WithThreshold(threshold time.Duration) *TimeSync {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.threshold = threshold
+	return t
+}
+
+// WithHistorySize sets the count of checks which will be remembered (see History).
+func (t *TimeSync) WithHistorySize(size int) *TimeSync {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.historySize = size
+	t.trimHistory()
+	return t
+}
+
+// Check compares the device clock with the host clock and corrects the device clock if the drift is greater than the
+// threshold. The result will be added to the history.
+func (t *TimeSync) Check(ctx context.Context) (DriftRecord, error) {
+	t.mu.Lock()
+	threshold := t.threshold
+	t.mu.Unlock()
+
+	record := DriftRecord{}
+
+	record.Err = t.do(ctx, func(client *Client) error {
+		requestedAt := t.now()
+		states, err := client.ReadStates(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to read device time: %w", err)
+		}
+		receivedAt := t.now()
+
+		//the device time was taken somewhere between request and response
+		record.CheckedAt = requestedAt.Add(receivedAt.Sub(requestedAt) / 2)
+		record.Drift = states.CurrentTime().Sub(record.CheckedAt)
+
+		if record.Drift <= threshold && record.Drift >= -threshold {
+			return nil
+		}
+
+		if err := client.UpdateTime(ctx, t.pin, t.now()); err != nil {
+			return fmt.Errorf("unable to correct device time (drift %s): %w", record.Drift, err)
+		}
+		record.Corrected = true
+		return nil
+	})
+	if record.CheckedAt.IsZero() {
+		record.CheckedAt = t.now()
+	}
+
+	t.mu.Lock()
+	t.history = append(t.history, record)
+	t.trimHistory()
+	t.mu.Unlock()
+
+	return record, record.Err
+}
+
+func (t *TimeSync) trimHistory() {
+	if t.historySize >= 0 && len(t.history) > t.historySize {
+		t.history = append([]DriftRecord{}, t.history[len(t.history)-t.historySize:]...)
+	}
+}
+
+// History returns the results of the last checks (oldest first).
+func (t *TimeSync) History() []DriftRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]DriftRecord{}, t.history...)
+}
+
+// Run checks the device clock immediately and then in the given interval until the context is done. Failed checks
+// will be logged and recorded (see History) but will not stop the schedule. Returns the error of the context or
+// InvalidIntervalError if the interval is not positive.
+func (t *TimeSync) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w: %s", InvalidIntervalError, interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		record, err := t.Check(ctx)
+		if err != nil {
+			if logger.Info != nil {
+				logger.Info.Printf("Time sync has failed: %s", err.Error())
+			}
+		} else if record.Corrected && logger.Info != nil {
+			logger.Info.Printf("Device time was corrected (drift %s).", record.Drift)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package nuki
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func clockDevice(deviceTime time.Time) *scriptedCommunicator {
+	return &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdStates):
+				states := testStates(command.LockStateLocked, command.LockActionCompletionStatusSuccess)
+				payload := states.Payload()
+				binary.LittleEndian.PutUint16(payload[3:5], uint16(deviceTime.Year()))
+				copy(payload[5:10], []byte{byte(deviceTime.Month()), byte(deviceTime.Day()), byte(deviceTime.Hour()), byte(deviceTime.Minute()), byte(deviceTime.Second())})
+				return []command.Command{command.NewCommand(command.IdStates, payload)}
+			case cmd.Is(command.IdUpdateTime):
+				return completed()
+			}
+			return nil
+		},
+	}
+}
+
+func (s *scriptedCommunicator) sentOf(id command.Id) []command.Command {
+	var result []command.Command
+	for _, cmd := range s.sent {
+		if cmd.Is(id) {
+			result = append(result, cmd)
+		}
+	}
+	return result
+}
+
+func fixedClock(now time.Time) func() time.Time {
+	return func() time.Time {
+		return now
+	}
+}
+
+func TestTimeSync_Check(t *testing.T) {
+	now := time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC)
+	com := clockDevice(now.Add(10 * time.Second))
+	toTest := NewTimeSync(connectedTestClient(com), "1234")
+	toTest.now = fixedClock(now)
+
+	record, err := toTest.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, record.Drift)
+	assert.False(t, record.Corrected)
+	assert.Empty(t, com.sentOf(command.IdUpdateTime))
+	assert.Equal(t, []DriftRecord{record}, toTest.History())
+}
+
+func TestTimeSync_Check_Corrects(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	now := time.Date(2022, 10, 19, 11, 30, 42, 0, berlin)
+	com := clockDevice(now.UTC().Add(-5 * time.Minute))
+	toTest := NewTimeSync(connectedTestClient(com), "1234").WithThreshold(time.Minute)
+	toTest.now = fixedClock(now)
+
+	record, err := toTest.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, -5*time.Minute, record.Drift)
+	assert.True(t, record.Corrected)
+
+	updates := com.sentOf(command.IdUpdateTime)
+	if assert.Len(t, updates, 1) {
+		//the device time is set in UTC
+		assert.Equal(t, []byte{0xE6, 0x07, 0x0A, 0x13, 0x09, 0x1E, 0x2A}, []byte(updates[0].Payload()[0:7]))
+	}
+}
+
+func TestTimeSync_Check_Error(t *testing.T) {
+	now := time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC)
+	com := clockDevice(now.Add(time.Hour))
+	respond := com.respond
+	com.respond = func(cmd command.Command) []command.Command {
+		if cmd.Is(command.IdUpdateTime) {
+			return []command.Command{command.NewCommand(command.IdErrorReport, []byte{0x21, byte(command.IdUpdateTime), 0x00})}
+		}
+		return respond(cmd)
+	}
+	toTest := NewTimeSync(connectedTestClient(com), "1234")
+	toTest.now = fixedClock(now)
+
+	record, err := toTest.Check(context.Background())
+	var nukiErr *communication.NukiError
+	assert.ErrorAs(t, err, &nukiErr)
+	assert.Equal(t, err, record.Err)
+	assert.Equal(t, time.Hour, record.Drift)
+	assert.False(t, record.Corrected)
+	assert.Equal(t, []DriftRecord{record}, toTest.History())
+}
+
+func TestTimeSync_History(t *testing.T) {
+	now := time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC)
+	toTest := NewTimeSync(connectedTestClient(clockDevice(now)), "1234").WithHistorySize(2)
+	toTest.now = fixedClock(now)
+
+	for i := 0; i < 3; i++ {
+		_, err := toTest.Check(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Len(t, toTest.History(), 2)
+
+	toTest.WithHistorySize(1)
+	assert.Len(t, toTest.History(), 1)
+}
+
+func TestTimeSync_Run(t *testing.T) {
+	now := time.Date(2022, 10, 19, 9, 30, 42, 0, time.UTC)
+	toTest := NewTimeSync(connectedTestClient(clockDevice(now)), "1234")
+	toTest.now = fixedClock(now)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, toTest.Run(ctx, 10*time.Millisecond), context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, len(toTest.History()), 2)
+}
+
+func TestTimeSync_Run_InvalidInterval(t *testing.T) {
+	com := clockDevice(time.Now())
+	toTest := NewTimeSync(connectedTestClient(com), "1234")
+
+	assert.ErrorIs(t, toTest.Run(context.Background(), 0), InvalidIntervalError)
+	assert.ErrorIs(t, toTest.Run(context.Background(), -time.Second), InvalidIntervalError)
+	assert.Empty(t, com.sent, "the clock must not be checked")
+}
@@ -0,0 +1,152 @@
+package nuki
+
+import (
+	"fmt"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+)
+
+// InvalidTransitionError describes a change of the lock state which is not possible for the device. This may be a
+// hint for a misbehaving firmware (or missed states).
+type InvalidTransitionError struct {
+	DeviceType communication.DeviceType
+	From       command.LockState
+	To         command.LockState
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("invalid lock state transition: 0x%02X -> 0x%02X", uint8(e.From), uint8(e.To))
+}
+
+type lockStates map[command.LockState]struct{}
+
+func newLockStates(states ...command.LockState) lockStates {
+	result := lockStates{}
+	for _, state := range states {
+		result[state] = struct{}{}
+	}
+	return result
+}
+
+// smartLockTransitions contains the possible following lock states of each smart lock state. A transition may skip
+// at most one moving state (for example locked -> unlocked without unlocking), because the states can be observed by
+// polling too.
+var smartLockTransitions = map[command.LockState]lockStates{
+	command.LockStateUncalibrated: newLockStates(
+		command.LockStateSmartLockCalibration,
+	),
+	command.LockStateLocked: newLockStates(
+		command.LockStateSmartLockUnlocking, command.LockStateSmartLockLocking, command.LockStateSmartLockUnlocked,
+		command.LockStateSmartLockCalibration, command.LockStateUncalibrated,
+	),
+	command.LockStateSmartLockUnlocking: newLockStates(
+		command.LockStateSmartLockUnlocked, command.LockStateSmartLockUnlatching, command.LockStateSmartLockUnlockedLockAndGoActive,
+		command.LockStateLocked, command.LockStateSmartLockLocking,
+	),
+	command.LockStateSmartLockUnlocked: newLockStates(
+		command.LockStateSmartLockLocking, command.LockStateLocked, command.LockStateSmartLockUnlocking,
+		command.LockStateSmartLockUnlatching, command.LockStateSmartLockUnlatched, command.LockStateSmartLockUnlockedLockAndGoActive,
+		command.LockStateSmartLockCalibration, command.LockStateUncalibrated,
+	),
+	command.LockStateSmartLockLocking: newLockStates(
+		command.LockStateLocked, command.LockStateSmartLockUnlocked, command.LockStateSmartLockUnlocking,
+	),
+	command.LockStateSmartLockUnlatched: newLockStates(
+		command.LockStateSmartLockUnlocked, command.LockStateSmartLockUnlockedLockAndGoActive, command.LockStateSmartLockLocking,
+	),
+	command.LockStateSmartLockUnlockedLockAndGoActive: newLockStates(
+		command.LockStateSmartLockLocking, command.LockStateLocked, command.LockStateSmartLockUnlocked,
+		command.LockStateSmartLockUnlatching, command.LockStateSmartLockUnlatched,
+	),
+	command.LockStateSmartLockUnlatching: newLockStates(
+		command.LockStateSmartLockUnlatched, command.LockStateSmartLockUnlocked, command.LockStateSmartLockUnlockedLockAndGoActive,
+	),
+	command.LockStateSmartLockCalibration: newLockStates(
+		command.LockStateLocked, command.LockStateSmartLockUnlocked, command.LockStateUncalibrated,
+		command.LockStateSmartLockUnlocking, command.LockStateSmartLockLocking,
+	),
+}
+
+// smartLockWildcards are the states which can be entered from any state and can be left to any state.
+var smartLockWildcards = newLockStates(
+	command.LockStateSmartLockBootRun, command.LockStateSmartLockMotorBlocked, command.LockStateUndefined,
+)
+
+// openerTransitions contains the possible following lock states of each opener state. See smartLockTransitions.
+var openerTransitions = map[command.LockState]lockStates{
+	command.LockStateUncalibrated: newLockStates(
+		command.LockStateLocked,
+	),
+	command.LockStateLocked: newLockStates(
+		command.LockStateOpenerRTOActive, command.LockStateOpenerOpening, command.LockStateOpenerOpen, command.LockStateUncalibrated,
+	),
+	command.LockStateOpenerRTOActive: newLockStates(
+		command.LockStateLocked, command.LockStateOpenerOpening, command.LockStateOpenerOpen,
+	),
+	command.LockStateOpenerOpening: newLockStates(
+		command.LockStateOpenerOpen, command.LockStateLocked, command.LockStateOpenerRTOActive,
+	),
+	command.LockStateOpenerOpen: newLockStates(
+		command.LockStateLocked, command.LockStateOpenerRTOActive, command.LockStateOpenerOpening,
+	),
+}
+
+// openerWildcards are the states which can be entered from any state and can be left to any state.
+var openerWildcards = newLockStates(
+	command.LockStateOpenerBootRun, command.LockStateUndefined,
+)
+
+// IsValidLockStateTransition checks if the device can change its lock state from the one to the other. Unchanged
+// states and devices of unknown type are always valid.
+func IsValidLockStateTransition(deviceType communication.DeviceType, from, to command.LockState) bool {
+	if from == to {
+		return true
+	}
+
+	var transitions map[command.LockState]lockStates
+	var wildcards lockStates
+	switch deviceType {
+	case communication.DeviceTypeSmartLock:
+		transitions, wildcards = smartLockTransitions, smartLockWildcards
+	case communication.DeviceTypeOpener:
+		transitions, wildcards = openerTransitions, openerWildcards
+	default:
+		return true
+	}
+
+	if _, ok := wildcards[from]; ok {
+		return true
+	}
+	if _, ok := wildcards[to]; ok {
+		return true
+	}
+	_, ok := transitions[from][to]
+	return ok
+}
+
+// transitionTracker remembers the last observed lock state to detect invalid transitions.
+type transitionTracker struct {
+	known bool
+	last  command.LockState
+}
+
+// observe will remember the lock state of the given states. Returns an error if the transition from the last
+// observed lock state is not possible.
+func (t *transitionTracker) observe(deviceType communication.DeviceType, states command.StatesCommand) error {
+	if states == nil {
+		return nil
+	}
+
+	current := states.LockState()
+	last, known := t.last, t.known
+	t.last, t.known = current, true
+
+	if known && !IsValidLockStateTransition(deviceType, last, current) {
+		return &InvalidTransitionError{
+			DeviceType: deviceType,
+			From:       last,
+			To:         current,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package nuki
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarent/go-nuki/communication"
+	"github.com/tarent/go-nuki/communication/command"
+	"testing"
+	"time"
+)
+
+func TestIsValidLockStateTransition(t *testing.T) {
+	tests := []struct {
+		deviceType communication.DeviceType
+		from, to   command.LockState
+		valid      bool
+	}{
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateSmartLockUnlocking, true},
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateSmartLockUnlocked, true},
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateLocked, true},
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateSmartLockUnlatched, false},
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateSmartLockUnlatching, false},
+		{communication.DeviceTypeSmartLock, command.LockStateSmartLockUnlatching, command.LockStateSmartLockUnlatched, true},
+		{communication.DeviceTypeSmartLock, command.LockStateSmartLockUnlatched, command.LockStateLocked, false},
+		{communication.DeviceTypeSmartLock, command.LockStateLocked, command.LockStateSmartLockMotorBlocked, true},
+		{communication.DeviceTypeSmartLock, command.LockStateSmartLockMotorBlocked, command.LockStateSmartLockUnlatched, true},
+		{communication.DeviceTypeSmartLock, command.LockStateUncalibrated, command.LockStateLocked, false},
+		{communication.DeviceTypeOpener, command.LockStateLocked, command.LockStateOpenerRTOActive, true},
+		{communication.DeviceTypeOpener, command.LockStateOpenerRTOActive, command.LockStateOpenerOpening, true},
+		{communication.DeviceTypeOpener, command.LockStateUncalibrated, command.LockStateOpenerOpen, false},
+		{communication.DeviceTypeOpener, command.LockStateOpenerBootRun, command.LockStateOpenerOpen, true},
+		{communication.DeviceTypeUnknown, command.LockStateLocked, command.LockStateSmartLockUnlatched, true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.valid, IsValidLockStateTransition(test.deviceType, test.from, test.to),
+			"0x%02X -> 0x%02X (device 0x%02X)", uint8(test.from), uint8(test.to), test.deviceType)
+	}
+}
+
+func TestTransitionTracker_observe(t *testing.T) {
+	tracker := transitionTracker{}
+	states := func(lockState command.LockState) command.StatesCommand {
+		return testStates(lockState, command.LockActionCompletionStatusSuccess).AsStatesCommand()
+	}
+
+	assert.NoError(t, tracker.observe(communication.DeviceTypeSmartLock, states(command.LockStateLocked)))
+	assert.NoError(t, tracker.observe(communication.DeviceTypeSmartLock, states(command.LockStateSmartLockUnlocking)))
+	assert.NoError(t, tracker.observe(communication.DeviceTypeSmartLock, states(command.LockStateSmartLockUnlocked)))
+	assert.NoError(t, tracker.observe(communication.DeviceTypeSmartLock, states(command.LockStateLocked)))
+
+	err := tracker.observe(communication.DeviceTypeSmartLock, states(command.LockStateSmartLockUnlatched))
+	assert.Equal(t, &InvalidTransitionError{
+		DeviceType: communication.DeviceTypeSmartLock,
+		From:       command.LockStateLocked,
+		To:         command.LockStateSmartLockUnlatched,
+	}, err)
+}
+
+func TestClient_WaitForState_Polling(t *testing.T) {
+	defer func(interval time.Duration) { WaitForStatePollInterval = interval }(WaitForStatePollInterval)
+	WaitForStatePollInterval = time.Millisecond
+
+	lockStates := []command.LockState{command.LockStateSmartLockUnlocked, command.LockStateSmartLockLocking, command.LockStateLocked}
+	com := &scriptedCommunicator{
+		respond: func(cmd command.Command) []command.Command {
+			switch {
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdChallenge):
+				return []command.Command{command.NewCommand(command.IdChallenge, make([]byte, 32))}
+			case cmd.Is(command.IdRequestData) && cmd.Payload()[0] == byte(command.IdKeyturnerStates):
+				lockState := lockStates[0]
+				lockStates = lockStates[1:]
+				return []command.Command{testStates(lockState, command.LockActionCompletionStatusSuccess)}
+			}
+			return nil
+		},
+	}
+
+	states, err := connectedTestClient(com).WaitForState(context.Background(), LockStateIs(command.LockStateLocked))
+
+	assert.NoError(t, err)
+	assert.Equal(t, command.LockStateLocked, states.LockState())
+	assert.Empty(t, lockStates)
+}
+
+func TestClient_WaitForState_Pushed(t *testing.T) {
+	defer func(interval time.Duration) { WaitForStatePollInterval = interval }(WaitForStatePollInterval)
+	WaitForStatePollInterval = time.Hour
+
+	client := connectedTestClient(lockDevice(command.LockStateSmartLockUnlocked))
+
+	go func() {
+		for _, lockState := range []command.LockState{command.LockStateSmartLockLocking, command.LockStateLocked} {
+			time.Sleep(10 * time.Millisecond)
+			client.events.publish(Event{Type: EventTypeStates, Command: testStates(lockState, command.LockActionCompletionStatusSuccess)})
+		}
+	}()
+
+	states, err := client.WaitForState(context.Background(), LockStateIs(command.LockStateLocked))
+
+	assert.NoError(t, err)
+	assert.Equal(t, command.LockStateLocked, states.LockState())
+}
+
+func TestClient_WaitForState_Canceled(t *testing.T) {
+	defer func(interval time.Duration) { WaitForStatePollInterval = interval }(WaitForStatePollInterval)
+	WaitForStatePollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := connectedTestClient(lockDevice(command.LockStateSmartLockUnlocked)).WaitForState(ctx, DoorSensorStateIs(command.DoorSensorStateDoorOpened))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}